@@ -0,0 +1,204 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credential implements the credential helper protocol of fmcsadmin,
+// which is modeled on the credential helpers of Git.
+//
+// A helper that speaks the protocol is either a command prefixed with "!"
+// (e.g. "!/usr/local/bin/fms-keychain") or an executable named
+// "fmcsadmin-credential-*". It is run with the action ("get", "store" or
+// "erase") as its last argument. fmcsadmin writes the description of the
+// credential as "key=value" lines terminated by a blank line to the standard
+// input, and a helper answers "get" with "username=..." and "password=..."
+// lines on the standard output.
+//
+// Any other command (e.g. "pass show fms/prod") is run verbatim and only
+// for "get". It simply prints the password on the first line.
+package credential
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Credential is the description of a credential of the server.
+type Credential struct {
+	Protocol string
+	Host     string
+	Username string
+	Password string
+}
+
+// ForURL returns the description of a credential of the server at rawURL.
+func ForURL(rawURL string, username string) Credential {
+	c := Credential{Username: username}
+	if u, err := url.Parse(rawURL); err == nil {
+		c.Protocol = u.Scheme
+		c.Host = u.Host
+	}
+
+	return c
+}
+
+// String returns the credential in the format of the protocol.
+func (c Credential) String() string {
+	var b strings.Builder
+	for _, kv := range [][2]string{
+		{"protocol", c.Protocol},
+		{"host", c.Host},
+		{"username", c.Username},
+		{"password", c.Password},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "%s=%s\n", kv[0], kv[1])
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// Helper is a credential helper command.
+type Helper struct {
+	Command string
+}
+
+// NewHelper returns the credential helper to run command.
+func NewHelper(command string) *Helper {
+	return &Helper{Command: command}
+}
+
+// Get asks the helper for the credential. The returned credential has an
+// empty password when the helper does not know it.
+func (h *Helper) Get(c Credential) (Credential, error) {
+	out, err := h.run("get", c)
+	if err != nil {
+		return c, err
+	}
+
+	result := c
+	result.Password = ""
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSuffix(scanner.Text(), "\r"), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			result.Username = value
+			found = true
+		case "password":
+			result.Password = value
+			found = true
+		}
+	}
+
+	if !found {
+		// the first line of the output is the password
+		line := strings.SplitN(string(out), "\n", 2)[0]
+		result.Password = strings.TrimSuffix(line, "\r")
+	}
+
+	return result, nil
+}
+
+// Store asks the helper to save the credential accepted by the server.
+// A helper that does not speak the protocol is not asked.
+func (h *Helper) Store(c Credential) error {
+	if _, ok := h.protocolCommand(); !ok {
+		return nil
+	}
+
+	_, err := h.run("store", c)
+	return err
+}
+
+// Erase asks the helper to remove the credential rejected by the server.
+// A helper that does not speak the protocol is not asked.
+func (h *Helper) Erase(c Credential) error {
+	if _, ok := h.protocolCommand(); !ok {
+		return nil
+	}
+
+	_, err := h.run("erase", c)
+	return err
+}
+
+// protocolCommand returns the command to run and whether the helper speaks
+// the protocol.
+func (h *Helper) protocolCommand() (string, bool) {
+	command := strings.TrimSpace(h.Command)
+	if strings.HasPrefix(command, "!") {
+		return strings.TrimSpace(command[1:]), true
+	}
+
+	fields := strings.Fields(command)
+	if len(fields) > 0 {
+		name := strings.TrimSuffix(filepath.Base(fields[0]), ".exe")
+		if strings.HasPrefix(name, "fmcsadmin-credential-") {
+			return command, true
+		}
+	}
+
+	return command, false
+}
+
+func (h *Helper) run(action string, c Credential) ([]byte, error) {
+	command, protocol := h.protocolCommand()
+	if command == "" {
+		return nil, errors.New("no credential helper")
+	}
+
+	var cmd *exec.Cmd
+	if !protocol {
+		// run the command verbatim
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+	} else {
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command+" "+action)
+		} else {
+			// append the action to the arguments of the command
+			cmd = exec.Command("sh", "-c", command+` "$@"`, "sh", action)
+		}
+		cmd.Stdin = strings.NewReader(c.String())
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if !protocol {
+			return nil, fmt.Errorf("%s: %w", command, err)
+		}
+		return nil, fmt.Errorf("%s %s: %w", command, action, err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,112 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package credential
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForURL(t *testing.T) {
+	c := ForURL("https://fms.example.jp:8443", "admin")
+	assert.Equal(t, Credential{Protocol: "https", Host: "fms.example.jp:8443", Username: "admin"}, c)
+	assert.Equal(t, "protocol=https\nhost=fms.example.jp:8443\nusername=admin\n\n", c.String())
+}
+
+func TestHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell script")
+	}
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	script := filepath.Join(dir, "fmcsadmin-credential-test")
+	data := "#!/bin/sh\necho \"$1\" >> " + log + "\ncat >> " + log + "\nif [ \"$1\" = get ]; then\n  echo username=USERNAME\n  echo password=PASSWORD\nfi\n"
+	assert.Nil(t, os.WriteFile(script, []byte(data), 0700))
+
+	h := NewHelper(script)
+	c, err := h.Get(ForURL("https://fms.example.jp", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "USERNAME", c.Username)
+	assert.Equal(t, "PASSWORD", c.Password)
+
+	assert.Nil(t, h.Store(c))
+	assert.Nil(t, h.Erase(c))
+
+	out, err := os.ReadFile(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "get\nprotocol=https\nhost=fms.example.jp\n\n"+
+		"store\nprotocol=https\nhost=fms.example.jp\nusername=USERNAME\npassword=PASSWORD\n\n"+
+		"erase\nprotocol=https\nhost=fms.example.jp\nusername=USERNAME\npassword=PASSWORD\n\n", string(out))
+}
+
+func TestHelperWithMarker(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell command")
+	}
+
+	log := filepath.Join(t.TempDir(), "log")
+	h := NewHelper("!printf '%s\\n' >> " + log)
+	c, err := h.Get(ForURL("https://fms.example.jp", "admin"))
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", c.Username)
+	assert.Equal(t, "", c.Password)
+	assert.Nil(t, h.Store(c))
+	assert.Nil(t, h.Erase(c))
+
+	out, err := os.ReadFile(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "get\nstore\nerase\n", string(out))
+}
+
+func TestHelperPrintingPassword(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell command")
+	}
+
+	c, err := NewHelper("printf 'secret\\nurl: fms.example.jp\\n' #").Get(ForURL("https://fms.example.jp", "admin"))
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", c.Username)
+	assert.Equal(t, "secret", c.Password)
+
+	_, err = NewHelper("false").Get(ForURL("https://fms.example.jp", "admin"))
+	assert.NotNil(t, err)
+}
+
+func TestHelperRunVerbatim(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell command")
+	}
+
+	// the action is not appended and store/erase are never run
+	log := filepath.Join(t.TempDir(), "log")
+	h := NewHelper("echo secret | tee -a " + log)
+	c, err := h.Get(ForURL("https://fms.example.jp", "admin"))
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", c.Username)
+	assert.Equal(t, "secret", c.Password)
+	assert.Nil(t, h.Store(c))
+	assert.Nil(t, h.Erase(c))
+
+	out, err := os.ReadFile(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret\n", string(out))
+}
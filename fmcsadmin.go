@@ -19,30 +19,39 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/rsa"
 	"crypto/x509"
-	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
+	"net"
 	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path"
 	"path/filepath"
-	"reflect"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/template"
 	"time"
 
+	"github.com/emic/fmcsadmin/credential"
+	"github.com/emic/fmcsadmin/fmsadmin"
+	"github.com/emic/fmcsadmin/pki"
+	"github.com/emic/fmcsadmin/profile"
+	"github.com/emic/fmcsadmin/session"
+	"github.com/emic/fmcsadmin/tunnel"
 	jwt "github.com/golang-jwt/jwt/v5"
-	"github.com/mattn/go-scan"
 	"github.com/olekukonko/tablewriter"
 	"golang.org/x/term"
 )
@@ -51,172 +60,140 @@ var version string
 
 type cli struct {
 	outStream, errStream io.Writer
+	output               string             // output format specified by --output
+	noHeaders            bool               // whether to omit the header row of tables
+	format               string             // Go template specified by --format
+	settings             map[string]setting // settings to be output as JSON
 }
 
-type output struct {
-	Response struct {
-		Status string `json:"status"`
-		Token  string `json:"token"`
-	} `json:"response"`
-	Messages []struct {
-		Code string `json:"code"`
-		Text string `json:"text"`
-	} `json:"messages"`
+// setting is a configuration setting output by GET and SET commands.
+type setting struct {
+	Value   interface{} `json:"value"`
+	Default interface{} `json:"default,omitempty"`
+	Range   []int       `json:"range,omitempty"`
+	Options []string    `json:"options,omitempty"`
 }
 
-type generalOldConfigInfo struct {
-	CacheSize                 int  `json:"cacheSize"`
-	MaxFiles                  int  `json:"maxFiles"`
-	MaxProConnections         int  `json:"maxProConnections"`
-	MaxPSOS                   int  `json:"maxPSOS"`
-	StartupRestorationEnabled bool `json:"startupRestorationEnabled"`
+// clientInfo is a connected client output by LIST CLIENTS and STATUS CLIENT
+// commands.
+type clientInfo struct {
+	ID              int             `json:"id"`
+	UserName        string          `json:"userName"`
+	ComputerName    string          `json:"computerName"`
+	ExtPrivilege    string          `json:"extPrivilege"`
+	IPAddress       string          `json:"ipAddress"`
+	MACAddress      string          `json:"macAddress"`
+	ConnectTime     string          `json:"connectTime"`
+	ConnectDuration string          `json:"connectDuration"`
+	AppVersion      string          `json:"appVersion"`
+	AppLanguage     string          `json:"appLanguage"`
+	GuestFiles      []guestFileInfo `json:"guestFiles"`
 }
 
-type generalConfigInfo struct {
-	CacheSize         int `json:"cacheSize"`
-	MaxFiles          int `json:"maxFiles"`
-	MaxProConnections int `json:"maxProConnections"`
-	MaxPSOS           int `json:"maxPSOS"`
+type guestFileInfo struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	AccountName string `json:"accountName"`
+	PrivsetName string `json:"privsetName"`
 }
 
-type general211ConfigInfo struct {
-	CacheSize                   int  `json:"cacheSize"`
-	MaxFiles                    int  `json:"maxFiles"`
-	MaxProConnections           int  `json:"maxProConnections"`
-	MaxPSOS                     int  `json:"maxPSOS"`
-	OnlyOpenLastOpenedDatabases bool `json:"onlyOpenLastOpenedDatabases"`
+// fileInfo is a database output by LIST FILES and STATUS FILE commands.
+type fileInfo struct {
+	ID                   int      `json:"id"`
+	Filename             string   `json:"filename"`
+	Folder               string   `json:"folder"`
+	Status               string   `json:"status"`
+	Clients              int      `json:"clients"`
+	Size                 int64    `json:"size"`
+	IsEncrypted          bool     `json:"isEncrypted"`
+	DecryptHint          string   `json:"decryptHint"`
+	EnabledExtPrivileges []string `json:"enabledExtPrivileges"`
 }
 
-type securityConfigInfo struct {
-	RequireSecureDB bool `json:"requireSecureDB"`
-}
-
-type authenticatedStreamConfigInfo struct {
-	AuthenticatedStream int `json:"authenticatedStream"`
-}
-
-type parallelBackupConfigInfo struct {
-	ParallelBackupEnabled bool `json:"parallelBackupEnabled"`
-}
-
-type persistentCacheConfigInfo struct {
-	PersistCacheEnabled       bool `json:"persistentCache"`
-	SyncPersistCache          bool `json:"persistentCacheSync"`
-	DatabaseServerAutoRestart bool `json:"databaseServerAutoRestart"`
-}
-
-type blockNewUsersConfigInfo struct {
-	BlockNewUsersEnabled bool `json:"blockNewUsers"`
-}
-
-type enableHttpProtocolNetworkConfigInfo struct {
-	EnableHttpProtocolNetwork bool `json:"enableHTTPSTunneling"`
-}
-
-type phpConfigInfo struct {
-	Enabled              bool   `json:"enabled"`
-	CharacterEncoding    string `json:"characterEncoding"`
-	ErrorMessageLanguage string `json:"errorMessageLanguage"`
-	DataPreValidation    bool   `json:"dataPreValidation"`
-	UseFileMakerPhp      bool   `json:"useFileMakerPhp"`
-}
-
-type xmlConfigInfo struct {
-	Enabled bool `json:"enabled"`
-}
-
-type dbInfo struct {
+// scheduleInfo is a schedule output by LIST SCHEDULES and GET BACKUPTIME
+// commands.
+type scheduleInfo struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	LastRun string `json:"lastRun"`
+	NextRun string `json:"nextRun"`
+	Enabled bool   `json:"enabled"`
 	Status  string `json:"status"`
-	Key     string `json:"key"`
-	SaveKey bool   `json:"saveKey"`
-}
-
-type closeMessageInfo struct {
-	Status      string `json:"status"`
-	MessageText string `json:"messageText"`
-	Force       bool   `json:"force"`
-}
-
-type statusInfo struct {
-	Status string `json:"status"`
-}
-
-type messageInfo struct {
-	MessageText string `json:"messageText"`
 }
 
-type scheduleSettingInfo struct {
-	Enabled bool `json:"enabled"`
-}
-
-type creatingCsrInfo struct {
-	Subject  string `json:"subject"`
-	Password string `json:"password"`
-}
-
-type importingCertificateInfo struct {
-	Certificate              string `json:"certificate"`
-	PrivateKey               string `json:"privateKey"`
-	IntermediateCertificates string `json:"intermediateCertificates"`
-	Password                 string `json:"password"`
+// pluginInfo is a plug-in output by LIST PLUGINS command.
+type pluginInfo struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Filename string `json:"filename"`
+	Enabled  bool   `json:"enabled"`
 }
 
 type params struct {
-	command                     string
-	key                         string
-	messageText                 string
-	force                       bool
-	retry                       int
-	status                      string
-	enabled                     string
-	cachesize                   int
-	maxfiles                    int
-	maxproconnections           int
-	maxpsos                     int
-	startuprestorationenabled   bool
-	startuprestorationbuiltin   bool
-	requiresecuredb             string
-	authenticatedstream         int
-	parallelbackupenabled       string
-	persistcacheenabled         string
-	syncpersistcache            string
-	databaseserverautorestart   string
-	blocknewusersenabled        string
-	enablehttpprotocolnetwork   string
-	onlyopenlastopeneddatabases string
-	characterencoding           string
-	errormessagelanguage        string
-	dataprevalidation           bool
-	usefilemakerphp             bool
-	saveKey                     bool
-	subject                     string
-	password                    string
-	certificate                 string
-	privateKey                  string
-	intermediateCertificates    string
-	printRefreshToken           bool
-	identityFile                string
+	retry             int
+	printRefreshToken bool
+	identityFile      string
+	keyName           string
+	tokenLifetime     time.Duration
+	signerCommand     string
+	passwordCommand   string
+	credentialHelper  string
+	identity          *string
 }
 
 type commandOptions struct {
-	helpFlag       bool
-	versionFlag    bool
-	yesFlag        bool
-	statsFlag      bool
-	forceFlag      bool
-	saveKeyFlag    bool
-	fqdn           string
-	hostname       string
-	username       string
-	password       string
-	key            string
-	message        string
-	keyFile        string
-	keyFilePass    string
-	intermediateCA string
-	clientID       int
-	graceTime      int
-	identityFile   string
+	helpFlag         bool
+	versionFlag      bool
+	yesFlag          bool
+	statsFlag        bool
+	forceFlag        bool
+	saveKeyFlag      bool
+	noHeadersFlag    bool
+	fqdn             string
+	hostname         string
+	username         string
+	password         string
+	key              string
+	message          string
+	keyFile          string
+	keyFilePass      string
+	intermediateCA   string
+	clientID         int
+	graceTime        int
+	identityFile     string
+	output           string
+	format           string
+	profile          string
+	credentialHelper string
+	timeout          int
+	retries          int
+	caCert           string
+	cert             string
+	certKey          string
+	pinSHA256        string
+	insecureFlag     bool
+	serverURL        string
+	proxy            string
+	sshJump          string
+	keyName          string
+	tokenLifetime    string
+	signerCommand    string
+	passwordFile     string
+	passwordStdin    bool
+	keyFileForDB     string
+	keyFilePassFile  string
+	keyMap           string
+	waitFlag         bool
+	waitTimeout      string
+	match            string
+	exclude          string
+	dryRunFlag       bool
+	drainFlag        bool
+	drainTimeout     string
+	drainReminder    string
+	where            string
+	whereFlag        bool
 }
 
 func main() {
@@ -235,6 +212,7 @@ func (c *cli) Run(args []string) int {
 	statsFlag := false
 	forceFlag := false
 	saveKeyFlag := false
+	noHeadersFlag := false
 	graceTime := 90
 	fqdn := ""
 	hostname := ""
@@ -248,6 +226,10 @@ func (c *cli) Run(args []string) int {
 	keyFilePass := ""
 	intermediateCA := ""
 	identityFile := ""
+	output := ""
+	format := ""
+	timeout := -1
+	retries := -1
 
 	commandOptions := commandOptions{}
 	commandOptions.helpFlag = false
@@ -256,6 +238,7 @@ func (c *cli) Run(args []string) int {
 	commandOptions.statsFlag = false
 	commandOptions.forceFlag = false
 	commandOptions.saveKeyFlag = false
+	commandOptions.noHeadersFlag = false
 	commandOptions.fqdn = ""
 	commandOptions.hostname = ""
 	commandOptions.username = ""
@@ -268,6 +251,38 @@ func (c *cli) Run(args []string) int {
 	commandOptions.clientID = -1
 	commandOptions.graceTime = 90
 	commandOptions.identityFile = ""
+	commandOptions.output = ""
+	commandOptions.format = ""
+	commandOptions.profile = ""
+	commandOptions.credentialHelper = ""
+	commandOptions.timeout = -1
+	commandOptions.retries = -1
+	commandOptions.caCert = ""
+	commandOptions.cert = ""
+	commandOptions.certKey = ""
+	commandOptions.pinSHA256 = ""
+	commandOptions.insecureFlag = false
+	commandOptions.serverURL = ""
+	commandOptions.proxy = ""
+	commandOptions.sshJump = ""
+	commandOptions.keyName = ""
+	commandOptions.tokenLifetime = ""
+	commandOptions.signerCommand = ""
+	commandOptions.passwordFile = ""
+	commandOptions.passwordStdin = false
+	commandOptions.keyFileForDB = ""
+	commandOptions.keyFilePassFile = ""
+	commandOptions.keyMap = ""
+	commandOptions.waitFlag = false
+	commandOptions.waitTimeout = ""
+	commandOptions.match = ""
+	commandOptions.exclude = ""
+	commandOptions.dryRunFlag = false
+	commandOptions.drainFlag = false
+	commandOptions.drainTimeout = ""
+	commandOptions.drainReminder = ""
+	commandOptions.where = ""
+	commandOptions.whereFlag = false
 
 	// detect an invalid command
 	cmdArgs, cFlags, err := getFlags(args, commandOptions)
@@ -284,7 +299,7 @@ func (c *cli) Run(args []string) int {
 			// Allow option (ex.: "fmcsadmin get backuptime -1")
 			invalidOption = false
 		} else {
-			allowedOptions := []string{"-h", "-v", "-y", "-s", "-u", "-p", "-m", "-f", "-c", "-t", "-i", "--help", "--version", "--yes", "--stats", "--fqdn", "--host", "--username", "--password", "--key", "--message", "--force", "--client", "--gracetime", "--savekey", "--keyfile", "--KeyFile", "--keyfilepass", "--KeyFilePass", "--intermediateca", "--intermediateCA"}
+			allowedOptions := []string{"-h", "-v", "-y", "-s", "-u", "-p", "-m", "-f", "-c", "-t", "-i", "--help", "--version", "--yes", "--stats", "--fqdn", "--host", "--username", "--password", "--key", "--message", "--force", "--client", "--gracetime", "--savekey", "--keyfile", "--KeyFile", "--keyfilepass", "--KeyFilePass", "--intermediateca", "--intermediateCA", "-o", "--output", "--no-headers", "--format", "--profile", "--credential-helper", "--timeout", "--retries", "--cacert", "--cert", "--cert-key", "--pin-sha256", "--insecure", "--url", "--proxy", "--ssh-jump", "--key-name", "--token-lifetime", "--signer-command", "--password-file", "--password-stdin", "--key-file-for-db", "--keyfilepass-file", "--keymap", "--wait", "--wait-timeout", "--match", "--exclude", "--dry-run", "--drain", "--drain-timeout", "--drain-reminder", "--where", "-"}
 			for j := 0; j < len(allowedOptions); j++ {
 				if string([]rune(args[i])[:1]) == "-" {
 					invalidOption = true
@@ -305,12 +320,93 @@ func (c *cli) Run(args []string) int {
 		}
 	}
 
+	// read secrets from files or the standard input instead of the command line
+	err = readSecretOptions(&cFlags)
+	if err != nil {
+		fmt.Fprintln(c.outStream, err.Error())
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	}
+
+	// apply the profile (command-line options override the profile)
+	profileName := cFlags.profile
+	if profileName == "" {
+		profileName = os.Getenv(profile.EnvName)
+	}
+	passwordCommand := ""
+	credentialHelper := cFlags.credentialHelper
+	tlsOptions := fmsadmin.TLSOptions{}
+	if profileName != "" {
+		p, err := loadProfile(profileName)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid profile: "+err.Error())
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+		if cFlags.fqdn == "" && cFlags.hostname == "" && cFlags.serverURL == "" {
+			cFlags.fqdn = p.FQDN
+			cFlags.serverURL = p.URL
+		}
+		if cFlags.username == "" && cFlags.password == "" && cFlags.identityFile == "" && cFlags.signerCommand == "" {
+			cFlags.username = p.Username
+			cFlags.identityFile = p.IdentityFile
+			cFlags.signerCommand = p.SignerCommand
+			passwordCommand = p.PasswordCommand
+		}
+		if credentialHelper == "" {
+			credentialHelper = p.CredentialHelper
+		}
+		if cFlags.output == "" {
+			cFlags.output = p.Output
+		}
+		if cFlags.proxy == "" && cFlags.sshJump == "" {
+			cFlags.proxy = p.Proxy
+			cFlags.sshJump = p.SSHJump
+		}
+		if cFlags.keyName == "" {
+			cFlags.keyName = p.KeyName
+		}
+		if cFlags.tokenLifetime == "" {
+			cFlags.tokenLifetime = p.TokenLifetime
+		}
+		tlsOptions = fmsadmin.TLSOptions{
+			CACertFile: p.TLS.CACert,
+			CertFile:   p.TLS.Cert,
+			KeyFile:    p.TLS.Key,
+			PinSHA256:  p.TLS.PinSHA256,
+			Insecure:   p.TLS.Insecure,
+		}
+	}
+
+	// TLS options
+	if cFlags.caCert != "" {
+		tlsOptions.CACertFile = cFlags.caCert
+	}
+	if cFlags.cert != "" || cFlags.certKey != "" {
+		tlsOptions.CertFile = cFlags.cert
+		tlsOptions.KeyFile = cFlags.certKey
+	}
+	if cFlags.pinSHA256 != "" {
+		tlsOptions.PinSHA256 = cFlags.pinSHA256
+	}
+	tlsOptions.Insecure = tlsOptions.Insecure || cFlags.insecureFlag
+
 	helpFlag = cFlags.helpFlag
 	versionFlag = cFlags.versionFlag
 	yesFlag = cFlags.yesFlag
+	waitFlag := cFlags.waitFlag
+	dryRunFlag := cFlags.dryRunFlag
+	if dryRunFlag {
+		// nothing is changed, so that neither confirmation nor waiting is needed
+		yesFlag = true
+		waitFlag = false
+	}
 	statsFlag = cFlags.statsFlag
 	forceFlag = cFlags.forceFlag
 	saveKeyFlag = cFlags.saveKeyFlag
+	noHeadersFlag = cFlags.noHeadersFlag
 	graceTime = cFlags.graceTime
 	key = cFlags.key
 	username = cFlags.username
@@ -321,6 +417,30 @@ func (c *cli) Run(args []string) int {
 	keyFilePass = cFlags.keyFilePass
 	intermediateCA = cFlags.intermediateCA
 	identityFile = cFlags.identityFile
+	output = strings.ToLower(cFlags.output)
+	format = cFlags.format
+	timeout = cFlags.timeout
+	retries = cFlags.retries
+
+	switch output {
+	case "", "table", "json", "csv", "tsv":
+		c.output = output
+		c.noHeaders = noHeadersFlag
+		c.settings = nil
+	default:
+		fmt.Fprintln(c.outStream, "Invalid parameter for option: --output")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	}
+
+	c.format = format
+	if _, err := parseTemplate(format, ""); err != nil {
+		fmt.Fprintln(c.outStream, "Invalid parameter for option: --format")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	}
 
 	fqdn = cFlags.fqdn
 	hostname = cFlags.hostname
@@ -328,7 +448,129 @@ func (c *cli) Run(args []string) int {
 		fqdn = hostname + ".account.filemaker-cloud.com"
 	}
 	baseURI := getBaseURI(fqdn)
-	u, _ := url.Parse(baseURI)
+	if len(cFlags.serverURL) > 0 {
+		baseURI, err = parseServerURL(cFlags.serverURL)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --url")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+	localServer := baseURI == getBaseURI("")
+	client := fmsadmin.NewClient(baseURI)
+	if dryRunFlag {
+		client.DryRun = c.outStream
+	}
+	if timeout < -1 {
+		fmt.Fprintln(c.outStream, "Invalid parameter for option: --timeout")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	} else if timeout >= 0 {
+		// 0 means no time limit
+		client.HTTPClient.Timeout = time.Duration(timeout) * time.Second
+	}
+	selector := databaseSelector{exclude: cFlags.exclude}
+	if len(cFlags.match) > 0 {
+		selector.match, err = regexp.Compile(cFlags.match)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --match")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+	if _, err = path.Match(cFlags.exclude, ""); err != nil {
+		fmt.Fprintln(c.outStream, "Invalid parameter for option: --exclude")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	}
+
+	drainTimeout := defaultDrainTimeout
+	if len(cFlags.drainTimeout) > 0 {
+		drainTimeout, err = parseDuration(cFlags.drainTimeout)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --drain-timeout")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+	drainReminderInterval := defaultDrainReminderInterval
+	if len(cFlags.drainReminder) > 0 {
+		drainReminderInterval, err = parseDuration(cFlags.drainReminder)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --drain-reminder")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+
+	waitTimeout := defaultWaitTimeout
+	if len(cFlags.waitTimeout) > 0 {
+		waitTimeout, err = parseDuration(cFlags.waitTimeout)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --wait-timeout")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+	if retries < -1 {
+		fmt.Fprintln(c.outStream, "Invalid parameter for option: --retries")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	} else if retries >= 0 {
+		client.RetryPolicy.Retries = retries
+	}
+	if tlsOptions != (fmsadmin.TLSOptions{}) {
+		if tlsOptions.Insecure && strings.HasPrefix(baseURI, "https://") {
+			fmt.Fprintln(c.errStream, "fmcsadmin: WARNING: TLS certificate verification is disabled (--insecure).")
+			fmt.Fprintln(c.errStream, "fmcsadmin: WARNING: The connection to the server is NOT secure and the password or the token may be intercepted.")
+		}
+		err = client.SetTLSOptions(tlsOptions)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid TLS option: "+err.Error())
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+	if len(cFlags.proxy) > 0 && len(cFlags.sshJump) > 0 {
+		fmt.Fprintln(c.outStream, "Invalid parameter for option: --proxy and --ssh-jump cannot be used together")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	} else if len(cFlags.proxy) > 0 {
+		err = client.SetProxy(cFlags.proxy)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --proxy")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	} else if len(cFlags.sshJump) > 0 {
+		if _, _, err = tunnel.ParseTarget(cFlags.sshJump); err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --ssh-jump")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+		// connect to the Admin API port through the bastion host
+		jump := tunnel.NewJump(cFlags.sshJump)
+		defer jump.Close()
+		client.SetDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := jump.DialContext(ctx, network, addr)
+			if err != nil {
+				fmt.Fprintln(c.errStream, "fmcsadmin: "+err.Error())
+			}
+			return conn, err
+		})
+	}
 
 	usingCloud := false
 	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(baseURI)) {
@@ -336,11 +578,30 @@ func (c *cli) Run(args []string) int {
 		usingCloud = true
 	}
 
+	tokenLifetime := 15 * time.Minute
+	if len(cFlags.tokenLifetime) > 0 {
+		tokenLifetime, err = parseDuration(cFlags.tokenLifetime)
+		if err != nil {
+			fmt.Fprintln(c.outStream, "Invalid parameter for option: --token-lifetime")
+			exitStatus = 10001
+			outputErrorMessage(exitStatus, c)
+			return exitStatus
+		}
+	}
+
+	if len(cFlags.signerCommand) > 0 && len(cFlags.keyName) == 0 {
+		fmt.Fprintln(c.outStream, "--signer-command requires --key-name")
+		exitStatus = 10001
+		outputErrorMessage(exitStatus, c)
+		return exitStatus
+	}
+
 	retry := 3
-	if len(username) > 0 && len(password) > 0 {
+	if len(username) > 0 && (len(password) > 0 || len(passwordCommand) > 0) {
 		// Don't retry when specifying username and password
 		retry = 0
 	}
+	loginParams := params{retry: retry, identityFile: identityFile, keyName: cFlags.keyName, tokenLifetime: tokenLifetime, signerCommand: cFlags.signerCommand, passwordCommand: passwordCommand, credentialHelper: credentialHelper}
 
 	if len(cmdArgs) > 0 {
 		switch strings.ToLower(cmdArgs[0]) {
@@ -352,28 +613,29 @@ func (c *cli) Run(args []string) int {
 					switch strings.ToLower(cmdArgs[1]) {
 					case "backup":
 						running := true
-						u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-						_, err := http.Get(u.String())
+						res, err := client.HTTPClient.Get(client.URL("/server/metadata"))
 						if err != nil {
 							running = false
+						} else {
+							res.Body.Close()
 						}
 
 						if running {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
-								version := getServerVersion(u.String(), token)
+								version := getServerVersion(client)
 								if !usingCloud && version >= 19.5 {
-									u.Path = path.Join(getAPIBasePath(), "server", "cancelbackup")
-									exitStatus, _, err = sendRequest("POST", u.String(), token, params{command: "cancel backup"})
-									if err == nil {
+									err = client.CancelBackup()
+									exitStatus = getExitStatus(err)
+									if exitStatus == 0 {
 										fmt.Fprintln(c.outStream, "Command finished")
-									} else {
+									} else if exitStatus == -1 {
 										fmt.Fprintln(c.outStream, err.Error())
 									}
 								} else {
 									exitStatus = outputInvalidCommandErrorMessage(c)
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -395,16 +657,17 @@ func (c *cli) Run(args []string) int {
 					switch strings.ToLower(cmdArgs[1]) {
 					case "create":
 						running := true
-						u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-						_, err := http.Get(u.String())
+						res, err := client.HTTPClient.Get(client.URL("/server/metadata"))
 						if err != nil {
 							running = false
+						} else {
+							res.Body.Close()
 						}
 
 						if running {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
-								version := getServerVersion(u.String(), token)
+								version := getServerVersion(client)
 								if version >= 19.2 {
 									if len(cmdArgs) < 3 {
 										fmt.Fprintln(c.outStream, "Certificate subject is not specified.")
@@ -418,13 +681,13 @@ func (c *cli) Run(args []string) int {
 											fmt.Fprintln(c.outStream, "Invalid parameter for option: --KeyFilePass")
 											exitStatus = 10001
 										} else {
-											u.Path = path.Join(getAPIBasePath(), "server", "certificate", "csr")
-											exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "certificate create", subject: base64.StdEncoding.EncodeToString([]byte(cmdArgs[2])), password: keyFilePass})
+											err = client.CreateCSR(cmdArgs[2], keyFilePass)
+											exitStatus = getExitStatus(err)
 											if exitStatus == 1712 {
 												fmt.Fprintln(c.outStream, "Private key file already exists, please remove it and run the command again.")
 												exitStatus = 20406
 											} else {
-												if err != nil {
+												if exitStatus == -1 {
 													fmt.Fprintln(c.outStream, err.Error())
 												}
 											}
@@ -433,7 +696,7 @@ func (c *cli) Run(args []string) int {
 								} else {
 									exitStatus = outputInvalidCommandErrorMessage(c)
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -451,10 +714,9 @@ func (c *cli) Run(args []string) int {
 							res = strings.ToLower(strings.TrimSpace(input))
 						}
 						if res == "y" {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
-								u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-								version := getServerVersion(u.String(), token)
+								version := getServerVersion(client)
 								if version >= 19.2 {
 									if len(cmdArgs[2:]) > 0 {
 										keyFileData := []byte("")
@@ -506,41 +768,9 @@ func (c *cli) Run(args []string) int {
 														exitStatus = 20405
 													}
 												} else {
-													block, _ := pem.Decode(keyFileData)
-													if block == nil {
+													_, _, err = pki.ParsePrivateKey(keyFileData, []byte(keyFilePass))
+													if err != nil {
 														exitStatus = 20408
-													} else {
-														buf := block.Bytes
-														if x509.IsEncryptedPEMBlock(block) {
-															buf, err = x509.DecryptPEMBlock(block, []byte(keyFilePass))
-															if err != nil {
-																if err == x509.IncorrectPasswordError {
-																	exitStatus = 20408
-																}
-															}
-														}
-
-														if exitStatus == 0 {
-															switch block.Type {
-															case "RSA PRIVATE KEY":
-																_, err = x509.ParsePKCS1PrivateKey(buf)
-																if err != nil {
-																	exitStatus = 20408
-																}
-															case "PRIVATE KEY":
-																_, err := x509.ParsePKCS8PrivateKey(buf)
-																if err != nil {
-																	exitStatus = 20408
-																}
-															case "EC PRIVATE KEY":
-																_, err := x509.ParseECPrivateKey(buf)
-																if err != nil {
-																	exitStatus = 20408
-																}
-															default:
-																exitStatus = 20408
-															}
-														}
 													}
 												}
 
@@ -604,8 +834,8 @@ func (c *cli) Run(args []string) int {
 
 										// import SSL certficates
 										if exitStatus == 0 {
-											u.Path = path.Join(getAPIBasePath(), "server", "certificate", "import")
-											exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "certificate import", certificate: string(certificateData), privateKey: string(keyFileData), intermediateCertificates: string(intermediateCAData), password: keyFilePass})
+											err = client.ImportCertificate(string(certificateData), string(keyFileData), string(intermediateCAData), keyFilePass)
+											exitStatus = getExitStatus(err)
 
 											if exitStatus == 1712 {
 												fmt.Fprintln(c.outStream, "Private key file already exists, please remove it and run the command again.")
@@ -614,11 +844,11 @@ func (c *cli) Run(args []string) int {
 												fmt.Fprintln(c.outStream, "Failed to verify the intermediate CA certificate.")
 												exitStatus = 20630
 											} else {
-												if err != nil {
+												if exitStatus == -1 {
 													fmt.Fprintln(c.outStream, err.Error())
 												}
 											}
-											if exitStatus == 0 && err == nil {
+											if exitStatus == 0 {
 												fmt.Fprintln(c.outStream, "Restart the FileMaker Server background processes to apply the change.")
 											}
 										}
@@ -629,7 +859,7 @@ func (c *cli) Run(args []string) int {
 								} else {
 									exitStatus = outputInvalidCommandErrorMessage(c)
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -645,23 +875,22 @@ func (c *cli) Run(args []string) int {
 							res = strings.ToLower(strings.TrimSpace(input))
 						}
 						if res == "y" {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
-								u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-								version := getServerVersion(u.String(), token)
+								version := getServerVersion(client)
 								if version >= 19.2 {
-									u.Path = path.Join(getAPIBasePath(), "server", "certificate", "delete")
-									exitStatus, _, err = sendRequest("DELETE", u.String(), token, params{})
-									if err != nil {
+									err = client.DeleteCertificate()
+									exitStatus = getExitStatus(err)
+									if exitStatus == -1 {
 										fmt.Fprintln(c.outStream, err.Error())
 									}
-									if exitStatus == 0 && err == nil {
+									if exitStatus == 0 {
 										fmt.Fprintln(c.outStream, "Restart the FileMaker Server background processes to apply the change.")
 									}
 								} else {
 									exitStatus = outputInvalidCommandErrorMessage(c)
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -674,8 +903,10 @@ func (c *cli) Run(args []string) int {
 				}
 			}
 		case "close":
+			patternSelected := selector.isSet() || hasGlobPattern(cmdArgs[1:])
 			res := ""
-			if yesFlag {
+			if yesFlag || patternSelected {
+				// the databases selected by patterns are confirmed after being listed
 				res = "y"
 			} else {
 				r := bufio.NewReader(os.Stdin)
@@ -684,31 +915,62 @@ func (c *cli) Run(args []string) int {
 				res = strings.ToLower(strings.TrimSpace(input))
 			}
 			if res == "y" {
-				token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+				token, exitStatus, err = login(client, username, password, loginParams)
 				if token != "" && exitStatus == 0 && err == nil {
-					u.Path = path.Join(getAPIBasePath(), "databases")
 					args = []string{""}
 					if len(cmdArgs[1:]) > 0 {
 						args = cmdArgs[1:]
 					}
-					idList, nameList, _ := getDatabases(u.String(), token, args, "NORMAL", false)
+					idList, nameList, _, patternUsed := selectDatabasesWithPatterns(client, args, "NORMAL", false, selector)
 					if len(idList) > 0 {
-						for i := 0; i < len(idList); i++ {
-							fmt.Fprintln(c.outStream, "File Closing: "+nameList[i])
+						confirmed := true
+						if selector.isSet() || patternUsed {
+							confirmed = confirmDatabases(c, nameList, "close", yesFlag)
+						} else if patternSelected && !yesFlag {
+							// the arguments are the names of the databases
+							r := bufio.NewReader(os.Stdin)
+							fmt.Fprint(c.outStream, "fmcsadmin: really close database(s)? (y, n) ")
+							input, _ := r.ReadString('\n')
+							confirmed = strings.ToLower(strings.TrimSpace(input)) == "y"
 						}
-						connectedClients := getClients(u.String(), token, args)
-						for i := 0; i < len(idList); i++ {
-							u.Path = path.Join(getAPIBasePath(), "databases", strconv.Itoa(idList[i]))
-							exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "close", messageText: message, force: forceFlag})
-							if exitStatus == 0 && err == nil && len(connectedClients) == 0 {
-								// Don't output this message when the clients connected to the specified databases are existing
-								fmt.Fprintln(c.outStream, "File Closed: "+nameList[i])
+						if confirmed {
+							for i := 0; i < len(idList); i++ {
+								fmt.Fprintln(c.outStream, "File Closing: "+nameList[i])
+							}
+							results := make([]int, len(idList))
+							if cFlags.drainFlag {
+								// blocking new users is supported by Claris FileMaker Server 21.0 or later
+								blockNewUsers := !usingCloud && getServerVersion(client) >= 21.0
+								results = drainDatabases(c, client, idList, nameList, message, forceFlag, blockNewUsers, drainTimeout, drainReminderInterval)
+								exitStatus = 0
+								for i := 0; i < len(results); i++ {
+									if results[i] != 0 {
+										exitStatus = results[i]
+									}
+								}
+							} else {
+								if patternSelected {
+									// patterns are not file names of the guest files
+									args = nameList
+								}
+								connectedClients := getClients(client, args)
+								for i := 0; i < len(idList); i++ {
+									exitStatus = getExitStatus(client.CloseDatabase(idList[i], message, forceFlag))
+									results[i] = exitStatus
+									if exitStatus == 0 && len(connectedClients) == 0 {
+										// Don't output this message when the clients connected to the specified databases are existing
+										fmt.Fprintln(c.outStream, "File Closed: "+nameList[i])
+									}
+								}
+							}
+							if waitFlag {
+								exitStatus = waitForDatabases(c, client, idList, nameList, results, "NORMAL", "CLOSED", waitTimeout)
 							}
 						}
 					} else {
 						exitStatus = 10904
 					}
-					logout(baseURI, token)
+					logout(client)
 				} else if detectHostUnreachable(exitStatus) {
 					exitStatus = 10502
 				}
@@ -727,7 +989,7 @@ func (c *cli) Run(args []string) int {
 						res = strings.ToLower(strings.TrimSpace(input))
 					}
 					if res == "y" {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
 							id := 0
 							if len(cmdArgs) >= 3 {
@@ -737,13 +999,13 @@ func (c *cli) Run(args []string) int {
 								}
 							}
 							if id > 0 {
-								u.Path = path.Join(getAPIBasePath(), "schedules", strconv.Itoa(id))
-								scheduleName := getScheduleName(u.String(), token, id)
-								exitStatus, _, err = sendRequest("DELETE", u.String(), token, params{})
-								if err != nil {
+								scheduleName := getScheduleName(client, id)
+								err = client.DeleteSchedule(id)
+								exitStatus = getExitStatus(err)
+								if exitStatus == -1 {
 									fmt.Fprintln(c.outStream, err.Error())
 								}
-								if exitStatus == 0 && err == nil {
+								if exitStatus == 0 {
 									if scheduleName != "" {
 										fmt.Fprintln(c.outStream, "Schedule Deleted: "+scheduleName)
 									} else {
@@ -753,7 +1015,7 @@ func (c *cli) Run(args []string) int {
 							} else {
 								exitStatus = 10600
 							}
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -776,7 +1038,7 @@ func (c *cli) Run(args []string) int {
 						res = strings.ToLower(strings.TrimSpace(input))
 					}
 					if res == "y" {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
 							id := 0
 							if len(cmdArgs) >= 3 {
@@ -786,16 +1048,14 @@ func (c *cli) Run(args []string) int {
 								}
 							}
 							if id > 0 {
-								u.Path = path.Join(getAPIBasePath(), "schedules", strconv.Itoa(id))
-								exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "disable"})
-								if exitStatus == 0 && err == nil {
-									u.Path = path.Join(getAPIBasePath(), "schedules")
-									exitStatus = listSchedules(u.String(), token, id)
+								exitStatus = getExitStatus(client.DisableSchedule(id))
+								if exitStatus == 0 {
+									exitStatus = listSchedules(c, client, id)
 								}
 							} else {
 								exitStatus = 10600
 							}
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -810,62 +1070,78 @@ func (c *cli) Run(args []string) int {
 			if len(cmdArgs[1:]) > 0 {
 				switch strings.ToLower(cmdArgs[1]) {
 				case "client":
-					res := ""
-					if yesFlag {
-						res = "y"
+					if cFlags.whereFlag {
+						filter, err := parseClientFilter(cFlags.where)
+						if err != nil {
+							fmt.Fprintln(c.outStream, "Invalid parameter for option: --where ("+err.Error()+")")
+							exitStatus = 10001
+						} else if len(cmdArgs) >= 3 {
+							fmt.Fprintln(c.outStream, "CLIENT_NUMBER and --where cannot be used together")
+							exitStatus = 10001
+						} else {
+							token, exitStatus, err = login(client, username, password, loginParams)
+							if token != "" && exitStatus == 0 && err == nil {
+								exitStatus = disconnectMatchingClients(c, client, filter, message, graceTime, yesFlag)
+								logout(client)
+							} else if detectHostUnreachable(exitStatus) {
+								exitStatus = 10502
+							}
+						}
 					} else {
-						r := bufio.NewReader(os.Stdin)
-						fmt.Fprint(c.outStream, "fmcsadmin: really disconnect client(s)? (y, n) ")
-						input, _ := r.ReadString('\n')
-						res = strings.ToLower(strings.TrimSpace(input))
-					}
-					if res == "y" {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
-						if token != "" && exitStatus == 0 && err == nil {
-							id := 0
-							if len(cmdArgs) >= 3 {
-								cid, err := strconv.Atoi(cmdArgs[2])
-								if err == nil {
-									id = cid
-								}
-								if cid == 0 {
-									exitStatus = 11005
+						res := ""
+						if yesFlag {
+							res = "y"
+						} else {
+							r := bufio.NewReader(os.Stdin)
+							fmt.Fprint(c.outStream, "fmcsadmin: really disconnect client(s)? (y, n) ")
+							input, _ := r.ReadString('\n')
+							res = strings.ToLower(strings.TrimSpace(input))
+						}
+						if res == "y" {
+							token, exitStatus, err = login(client, username, password, loginParams)
+							if token != "" && exitStatus == 0 && err == nil {
+								id := 0
+								if len(cmdArgs) >= 3 {
+									cid, err := strconv.Atoi(cmdArgs[2])
+									if err == nil {
+										id = cid
+									}
+									if cid == 0 {
+										exitStatus = 11005
+									}
 								}
-							}
-							if id > -1 && exitStatus == 0 {
-								if id == 0 {
-									// disconnect clients
-									exitStatus, _ = disconnectAllClient(u, token, message, graceTime)
-								} else {
-									// check the client connection
-									u.Path = path.Join(getAPIBasePath(), "clients")
-									idList := getClients(u.String(), token, []string{""})
-									connected := false
-									if len(idList) > 0 && id > 0 {
-										for i := 0; i < len(idList); i++ {
-											if id == idList[i] {
-												connected = true
-												break
+								if id > -1 && exitStatus == 0 {
+									if id == 0 {
+										// disconnect clients
+										exitStatus, _ = disconnectAllClient(client, message, graceTime)
+									} else {
+										// check the client connection
+										idList := getClients(client, []string{""})
+										connected := false
+										if len(idList) > 0 && id > 0 {
+											for i := 0; i < len(idList); i++ {
+												if id == idList[i] {
+													connected = true
+													break
+												}
 											}
 										}
-									}
 
-									if connected {
-										// disconnect a client
-										u.Path = path.Join(getAPIBasePath(), "clients", strconv.Itoa(id))
-										u.RawQuery = "messageText=" + url.QueryEscape(message) + "&graceTime=" + url.QueryEscape(strconv.Itoa(graceTime))
-										exitStatus, _, _ = sendRequest("DELETE", u.String(), token, params{command: "disconnect"})
-									} else {
-										exitStatus = 11005
+										if connected {
+											// disconnect a client
+											exitStatus = getExitStatus(client.DisconnectClient(id, message, graceTime))
+										} else {
+											exitStatus = 11005
+										}
+									}
+									if exitStatus == 0 {
+										fmt.Fprintln(c.outStream, "Client(s) being disconnected.")
 									}
 								}
-								if exitStatus == 0 {
-									fmt.Fprintln(c.outStream, "Client(s) being disconnected.")
-								}
+								logout(client)
+							} else if detectHostUnreachable(exitStatus) {
+								exitStatus = 10502
 							}
-							logout(baseURI, token)
-						} else if detectHostUnreachable(exitStatus) {
-							exitStatus = 10502
 						}
 					}
 				default:
@@ -876,7 +1152,7 @@ func (c *cli) Run(args []string) int {
 			}
 		case "enable":
 			if len(cmdArgs[1:]) > 0 {
-				token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+				token, exitStatus, err = login(client, username, password, loginParams)
 				if token != "" && exitStatus == 0 && err == nil {
 					switch strings.ToLower(cmdArgs[1]) {
 					case "schedule":
@@ -888,11 +1164,9 @@ func (c *cli) Run(args []string) int {
 							}
 						}
 						if id > 0 {
-							u.Path = path.Join(getAPIBasePath(), "schedules", strconv.Itoa(id))
-							exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "enable"})
-							if exitStatus == 0 && err == nil {
-								u.Path = path.Join(getAPIBasePath(), "schedules")
-								exitStatus = listSchedules(u.String(), token, id)
+							exitStatus = getExitStatus(client.EnableSchedule(id))
+							if exitStatus == 0 {
+								exitStatus = listSchedules(c, client, id)
 							}
 						} else {
 							exitStatus = 10600
@@ -900,7 +1174,7 @@ func (c *cli) Run(args []string) int {
 					default:
 						exitStatus = 11002
 					}
-					logout(baseURI, token)
+					logout(client)
 				} else if detectHostUnreachable(exitStatus) {
 					exitStatus = 10502
 				}
@@ -914,7 +1188,7 @@ func (c *cli) Run(args []string) int {
 					if usingCloud {
 						exitStatus = 21
 					} else {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
 							id := 0
 							if len(cmdArgs) >= 3 {
@@ -923,9 +1197,8 @@ func (c *cli) Run(args []string) int {
 									id = sid
 								}
 							}
-							u.Path = path.Join(getAPIBasePath(), "schedules")
-							exitStatus = getBackupTime(u.String(), token, id)
-							logout(baseURI, token)
+							exitStatus = getBackupTime(c, client, id)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -984,19 +1257,18 @@ func (c *cli) Run(args []string) int {
 						}
 
 						if exitStatus == 0 {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
-								u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-								version := getServerVersion(u.String(), token)
-								if runtime.GOOS == "linux" && fqdn == "" && version < 19.6 {
+								version := getServerVersion(client)
+								if runtime.GOOS == "linux" && localServer && version < 19.6 {
 									// Not Supported
 									exitStatus = 21
 								} else {
 									if exitStatus == 0 {
-										_, exitStatus, _ = getWebTechnologyConfigurations(baseURI, getAPIBasePath(), token, printOptions)
+										_, exitStatus, _ = getWebTechnologyConfigurations(c, client, printOptions)
 									}
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -1004,9 +1276,11 @@ func (c *cli) Run(args []string) int {
 					}
 				case "refreshtoken":
 					if usingCloud {
-						token, exitStatus, err = login(baseURI, username, password, params{printRefreshToken: true, retry: retry, identityFile: identityFile})
+						refreshTokenParams := loginParams
+						refreshTokenParams.printRefreshToken = true
+						token, exitStatus, err = login(client, username, password, refreshTokenParams)
 						if token != "" && exitStatus == 0 && err == nil {
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -1036,7 +1310,7 @@ func (c *cli) Run(args []string) int {
 						}
 
 						if exitStatus == 0 {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
 								printOptions := []string{}
 								if len(cmdArgs[2:]) > 0 {
@@ -1067,10 +1341,9 @@ func (c *cli) Run(args []string) int {
 									printOptions = append(printOptions, "securefilesonly")
 								}
 								if exitStatus == 0 {
-									u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-									_, exitStatus = getServerGeneralConfigurations(u.String(), token, printOptions)
+									_, exitStatus = getServerGeneralConfigurations(c, client, printOptions)
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -1104,7 +1377,7 @@ func (c *cli) Run(args []string) int {
 					}
 
 					if exitStatus == 0 {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
 							var versionString string
 							var version float64
@@ -1115,8 +1388,7 @@ func (c *cli) Run(args []string) int {
 								printOptions = append(printOptions, "authenticatedstream")
 							} else {
 								// for Claris FileMaker Server
-								u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-								versionString, _ = getServerVersionString(u.String(), token)
+								versionString, _ = getServerVersionString(client)
 								version, _ = getServerVersionAsFloat(versionString)
 
 								if len(cmdArgs[2:]) > 0 {
@@ -1191,16 +1463,14 @@ func (c *cli) Run(args []string) int {
 
 							if exitStatus == 0 {
 								if !usingCloud {
-									u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-									_, exitStatus = getServerGeneralConfigurations(u.String(), token, printOptions)
+									_, exitStatus = getServerGeneralConfigurations(c, client, printOptions)
 								}
 
 								for _, option := range printOptions {
 									if option == "authenticatedstream" {
 										if usingCloud {
 											// for Claris FileMaker Cloud
-											u.Path = path.Join(getAPIBasePath(), "server", "config", "authenticatedstream")
-											_, exitStatus, _ = getAuthenticatedStreamSetting(u.String(), token, printOptions)
+											_, exitStatus, _ = getAuthenticatedStreamSetting(c, client, printOptions)
 										} else {
 											// for Claris FileMaker Server
 											if version < 19.3 || strings.HasPrefix(versionString, "19.3.1") {
@@ -1259,7 +1529,7 @@ func (c *cli) Run(args []string) int {
 								}
 							}
 
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -1297,10 +1567,16 @@ func (c *cli) Run(args []string) int {
 					fmt.Fprint(c.outStream, helpTextTemplate)
 				case "list":
 					fmt.Fprint(c.outStream, listHelpTextTemplate)
+				case "login":
+					fmt.Fprint(c.outStream, loginHelpTextTemplate)
+				case "logout":
+					fmt.Fprint(c.outStream, logoutHelpTextTemplate)
 				case "open":
 					fmt.Fprint(c.outStream, openHelpTextTemplate)
 				case "pause":
 					fmt.Fprint(c.outStream, pauseHelpTextTemplate)
+				case "pki":
+					fmt.Fprint(c.outStream, pkiHelpTextTemplate)
 				case "remove":
 					fmt.Fprint(c.outStream, removeHelpTextTemplate)
 				case "restart":
@@ -1329,28 +1605,26 @@ func (c *cli) Run(args []string) int {
 			if len(cmdArgs[1:]) > 0 {
 				switch strings.ToLower(cmdArgs[1]) {
 				case "clients":
-					token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+					token, exitStatus, err = login(client, username, password, loginParams)
 					if token != "" && exitStatus == 0 && err == nil {
 						id := -1
 						if statsFlag {
 							id = 0
 						}
-						u.Path = path.Join(getAPIBasePath(), "clients")
-						exitStatus = listClients(u.String(), token, id)
-						logout(baseURI, token)
+						exitStatus = listClients(c, client, id)
+						logout(client)
 					} else if detectHostUnreachable(exitStatus) {
 						exitStatus = 10502
 					}
 				case "files":
-					token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+					token, exitStatus, err = login(client, username, password, loginParams)
 					if token != "" && exitStatus == 0 && err == nil {
 						idList := []int{-1}
 						if statsFlag {
 							idList = []int{0}
 						}
-						u.Path = path.Join(getAPIBasePath(), "databases")
-						exitStatus = listFiles(c, u.String(), token, idList)
-						logout(baseURI, token)
+						exitStatus = listFiles(c, client, idList)
+						logout(client)
 					} else if detectHostUnreachable(exitStatus) {
 						exitStatus = 10502
 					}
@@ -1358,34 +1632,29 @@ func (c *cli) Run(args []string) int {
 					if usingCloud {
 						exitStatus = 21
 					} else {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
-							u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-							version := getServerVersion(u.String(), token)
+							version := getServerVersion(client)
 							if version >= 19.2 {
-								u.Path = path.Join(getAPIBasePath(), "plugins")
-								exitStatus = listPlugins(u.String(), token)
+								exitStatus = listPlugins(c, client)
 							} else {
-								var running string
-								u.Path = path.Join(getAPIBasePath(), "server", "status")
-								_, running, _ = sendRequest("GET", u.String(), token, params{})
+								running, _ := client.ServerStatus()
 								if running == "STOPPED" {
 									exitStatus = 10502
 								} else {
 									exitStatus = outputInvalidCommandErrorMessage(c)
 								}
 							}
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
 					}
 				case "schedules":
-					token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+					token, exitStatus, err = login(client, username, password, loginParams)
 					if token != "" && exitStatus == 0 && err == nil {
-						u.Path = path.Join(getAPIBasePath(), "schedules")
-						exitStatus = listSchedules(u.String(), token, 0)
-						logout(baseURI, token)
+						exitStatus = listSchedules(c, client, 0)
+						logout(client)
 					} else if detectHostUnreachable(exitStatus) {
 						exitStatus = 10502
 					}
@@ -1395,88 +1664,209 @@ func (c *cli) Run(args []string) int {
 			} else {
 				exitStatus = outputInvalidCommandErrorMessage(c)
 			}
+		case "login":
+			// log out of the previous session before caching a new token
+			cache := getSessionCache()
+			if cachedToken, _, _ := cache.Get(client.BaseURL); cachedToken != "" {
+				client.Token = cachedToken
+				_ = client.Logout()
+			}
+			_ = cache.Delete(client.BaseURL)
+
+			identity := ""
+			loginParams.identity = &identity
+			token, exitStatus, err = login(client, username, password, loginParams)
+			if token != "" && exitStatus == 0 && err == nil {
+				err = cache.Put(client.BaseURL, token, identity)
+				if err != nil {
+					fmt.Fprintln(c.outStream, err.Error())
+					logout(client)
+					exitStatus = -1
+				}
+			} else if detectHostUnreachable(exitStatus) {
+				exitStatus = 10502
+			}
+		case "logout":
+			cache := getSessionCache()
+			cachedToken, _, _ := cache.Get(client.BaseURL)
+			if cachedToken != "" {
+				client.Token = cachedToken
+				err = client.Logout()
+				exitStatus = getExitStatus(err)
+				if isInvalidTokenError(err) {
+					// the session has already expired
+					exitStatus = 0
+				} else if exitStatus == -1 {
+					fmt.Fprintln(c.outStream, err.Error())
+				}
+			}
+			err = cache.Delete(client.BaseURL)
+			if err != nil && exitStatus == 0 {
+				fmt.Fprintln(c.outStream, err.Error())
+				exitStatus = -1
+			}
 		case "open":
-			token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+			var keyMap map[string]keyMapEntry
+			if len(cFlags.keyMap) > 0 {
+				if len(key) > 0 {
+					fmt.Fprintln(c.outStream, "--key and --keymap cannot be used together")
+					exitStatus = 10001
+				} else {
+					keyMap, err = loadKeyMap(cFlags.keyMap)
+					if err != nil {
+						fmt.Fprintln(c.outStream, "Invalid key map file: "+err.Error())
+						exitStatus = 10001
+					}
+				}
+				if exitStatus != 0 {
+					break
+				}
+			}
+
+			token, exitStatus, err = login(client, username, password, loginParams)
 			if token != "" && exitStatus == 0 && err == nil {
-				u.Path = path.Join(getAPIBasePath(), "databases")
 				args = []string{""}
 				if len(cmdArgs[1:]) > 0 {
 					args = cmdArgs[1:]
 				}
-				idList, nameList, hintList := getDatabases(u.String(), token, args, "CLOSED", false)
+				idList, nameList, hintList := selectDatabases(client, args, "CLOSED", false, selector)
 				if len(idList) > 0 {
-					if usingCloud && (len(key) > 0 || saveKeyFlag) {
+					if usingCloud && (len(key) > 0 || saveKeyFlag || keyMap != nil) {
 						if len(key) > 0 {
 							exitStatus = outputInvalidOptionErrorMessage(c, "--key")
+						} else if keyMap != nil {
+							exitStatus = outputInvalidOptionErrorMessage(c, "--keymap")
 						} else {
 							exitStatus = outputInvalidOptionErrorMessage(c, "--savekey")
 						}
 					} else {
+						keyList := make([]string, len(idList))
+						saveKeyList := make([]bool, len(idList))
+						var skippedList []string
+						if keyMap != nil {
+							// use the encryption password of each database
+							encrypted := getEncryptedDatabases(client)
+							var ids []int
+							var names, hints []string
+							for i := 0; i < len(idList); i++ {
+								entry, ok := lookupKeyMap(keyMap, idList[i], nameList[i])
+								if !ok && encrypted[idList[i]] {
+									skippedList = append(skippedList, nameList[i])
+									continue
+								}
+								keyList[len(ids)] = entry.Key
+								saveKeyList[len(ids)] = entry.SaveKey || saveKeyFlag
+								ids = append(ids, idList[i])
+								names = append(names, nameList[i])
+								hints = append(hints, hintList[i])
+							}
+							idList, nameList, hintList = ids, names, hints
+						} else {
+							for i := 0; i < len(idList); i++ {
+								keyList[i] = key
+								saveKeyList[i] = saveKeyFlag
+							}
+						}
+
 						for i := 0; i < len(idList); i++ {
 							fmt.Fprintln(c.outStream, "File Opening: "+nameList[i])
 						}
+						results := make([]int, len(idList))
 						for i := 0; i < len(idList); i++ {
-							u.Path = path.Join(getAPIBasePath(), "databases", strconv.Itoa(idList[i]))
-							exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "open", key: key, saveKey: saveKeyFlag})
-							if exitStatus == 0 && err == nil {
-								// Note: FileMaker Admin API does not validate the encryption key.
-								//       You receive a result code of 0 even if you enter an invalid key.
-								var openedID []int
-								for value := 0; ; {
-									value++
-									u.Path = path.Join(getAPIBasePath(), "databases")
-									openedID, _, _ = getDatabases(u.String(), token, []string{strconv.Itoa(idList[i])}, "NORMAL", false)
-									if len(openedID) > 0 || value > 3 {
-										break
-									}
-									time.Sleep(1 * time.Second)
-								}
-								if len(openedID) > 0 {
+							exitStatus = getExitStatus(client.OpenDatabase(idList[i], keyList[i], saveKeyList[i]))
+							results[i] = exitStatus
+							if exitStatus == 0 {
+								if databaseOpened(client, idList[i]) {
 									fmt.Fprintln(c.outStream, "File Opened: "+nameList[i])
+								} else if keyMap != nil {
+									fmt.Fprintln(c.outStream, "Fail to open encrypted database. The correct password must be supplied in the key map file. (Hint: "+hintList[i]+")")
+									fmt.Fprintln(c.outStream, "File Closed: "+nameList[i])
+									results[i] = 802
 								} else {
 									fmt.Fprintln(c.outStream, "Fail to open encrypted database. The correct password must be supplied with the --key option. (Hint: "+hintList[i]+")")
 									fmt.Fprintln(c.outStream, "File Closed: "+nameList[i])
+									results[i] = 802
+								}
+							}
+						}
+						// prompt for the encryption passwords only on a terminal
+						interactive := !yesFlag && !usingCloud && term.IsTerminal(int(syscall.Stdin))
+						retryEncryptedDatabases(c, client, idList, nameList, hintList, saveKeyList, results, interactive)
+						if waitFlag {
+							exitStatus = waitForDatabases(c, client, idList, nameList, results, "CLOSED", "NORMAL", waitTimeout)
+						} else {
+							// report the first database that is still not open
+							for i := 0; i < len(results); i++ {
+								exitStatus = results[i]
+								if exitStatus != 0 {
+									break
 								}
 							}
 						}
+						for i := 0; i < len(skippedList); i++ {
+							fmt.Fprintln(c.outStream, "File Skipped (no encryption password in the key map file): "+skippedList[i])
+						}
 					}
 				} else {
 					exitStatus = 10904
 				}
-				logout(baseURI, token)
+				logout(client)
 			} else if detectHostUnreachable(exitStatus) {
 				exitStatus = 10502
 			}
-		case "pause":
-			token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
-			if token != "" && exitStatus == 0 && err == nil {
-				u.Path = path.Join(getAPIBasePath(), "databases")
-				args = []string{""}
-				if len(cmdArgs[1:]) > 0 {
-					args = cmdArgs[1:]
-				}
-				idList, nameList, _ := getDatabases(u.String(), token, args, "NORMAL", false)
-				if len(idList) > 0 {
-					for i := 0; i < len(idList); i++ {
+		case "pki":
+			if len(cmdArgs[1:]) > 0 {
+				switch strings.ToLower(cmdArgs[1]) {
+				case "keygen":
+					if len(cmdArgs) < 3 {
+						fmt.Fprintln(c.outStream, "Key name is not specified.")
+						exitStatus = 10001
+					} else {
+						exitStatus = generateKeyPair(c, cmdArgs[2], keyFilePass)
+					}
+				case "test":
+					exitStatus = testPKIAuthentication(c, client, loginParams)
+				default:
+					exitStatus = outputInvalidCommandErrorMessage(c)
+				}
+			} else {
+				exitStatus = outputInvalidCommandErrorMessage(c)
+			}
+		case "pause":
+			token, exitStatus, err = login(client, username, password, loginParams)
+			if token != "" && exitStatus == 0 && err == nil {
+				args = []string{""}
+				if len(cmdArgs[1:]) > 0 {
+					args = cmdArgs[1:]
+				}
+				idList, nameList, _ := selectDatabases(client, args, "NORMAL", false, selector)
+				if len(idList) > 0 {
+					for i := 0; i < len(idList); i++ {
 						fmt.Fprintln(c.outStream, "File Pausing: "+nameList[i])
 					}
+					results := make([]int, len(idList))
 					for i := 0; i < len(idList); i++ {
-						u.Path = path.Join(getAPIBasePath(), "databases", strconv.Itoa(idList[i]))
-						exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "pause"})
-						if exitStatus == 0 && err == nil {
+						exitStatus = getExitStatus(client.PauseDatabase(idList[i]))
+						results[i] = exitStatus
+						if exitStatus == 0 {
 							fmt.Fprintln(c.outStream, "File Paused: "+nameList[i])
 						}
 					}
+					if waitFlag {
+						exitStatus = waitForDatabases(c, client, idList, nameList, results, "NORMAL", "PAUSED", waitTimeout)
+					}
 				} else {
 					exitStatus = 10904
 				}
-				logout(baseURI, token)
+				logout(client)
 			} else if detectHostUnreachable(exitStatus) {
 				exitStatus = 10502
 			}
 		case "remove":
+			patternSelected := selector.isSet() || hasGlobPattern(cmdArgs[1:])
 			res := ""
-			if yesFlag {
+			if yesFlag || patternSelected {
+				// the databases selected by patterns are confirmed after being listed
 				res = "y"
 			} else {
 				r := bufio.NewReader(os.Stdin)
@@ -1485,30 +1875,39 @@ func (c *cli) Run(args []string) int {
 				res = strings.ToLower(strings.TrimSpace(input))
 			}
 			if res == "y" {
-				token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+				token, exitStatus, err = login(client, username, password, loginParams)
 				if token != "" && exitStatus == 0 && err == nil {
 					var version float64
 					if !usingCloud {
-						u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-						version = getServerVersion(u.String(), token)
+						version = getServerVersion(client)
 					}
 					if version >= 19.3 || usingCloud {
-						u.Path = path.Join(getAPIBasePath(), "databases")
 						args = []string{""}
 						if len(cmdArgs[1:]) > 0 {
 							args = cmdArgs[1:]
 						}
-						idList, nameList, _ := getDatabases(u.String(), token, args, "CLOSED", true)
+						idList, nameList, _, patternUsed := selectDatabasesWithPatterns(client, args, "CLOSED", true, selector)
 						if len(idList) > 0 {
-							for i := 0; i < len(idList); i++ {
-								u.Path = path.Join(getAPIBasePath(), "databases", strconv.Itoa(idList[i]))
-								exitStatus, _, err = sendRequest("DELETE", u.String(), token, params{})
-								if exitStatus == 0 && err == nil {
-									fmt.Fprintln(c.outStream, "File Removed: "+nameList[i])
+							confirmed := true
+							if selector.isSet() || patternUsed {
+								confirmed = confirmDatabases(c, nameList, "remove", yesFlag)
+							} else if patternSelected && !yesFlag {
+								// the arguments are the names of the databases
+								r := bufio.NewReader(os.Stdin)
+								fmt.Fprint(c.outStream, "fmcsadmin: really remove database(s)? (y, n) ")
+								input, _ := r.ReadString('\n')
+								confirmed = strings.ToLower(strings.TrimSpace(input)) == "y"
+							}
+							if confirmed {
+								for i := 0; i < len(idList); i++ {
+									exitStatus = getExitStatus(client.RemoveDatabase(idList[i]))
+									if exitStatus == 0 {
+										fmt.Fprintln(c.outStream, "File Removed: "+nameList[i])
+									}
 								}
 							}
 						} else {
-							_, nameList, _ = getDatabases(u.String(), token, args, "", true)
+							_, nameList, _ = getDatabases(client, args, "", true)
 							exitStatus = 10904
 							for i := 0; i < len(nameList); i++ {
 								if len(args) > 0 && comparePath(args[0], string(os.PathSeparator)+"Library"+string(os.PathSeparator)+"FileMaker Server"+string(os.PathSeparator)+"Data"+string(os.PathSeparator)+"Databases"+string(os.PathSeparator)) {
@@ -1526,7 +1925,7 @@ func (c *cli) Run(args []string) int {
 					} else {
 						exitStatus = outputInvalidCommandErrorMessage(c)
 					}
-					logout(baseURI, token)
+					logout(client)
 				} else if detectHostUnreachable(exitStatus) {
 					exitStatus = 10502
 				}
@@ -1548,19 +1947,22 @@ func (c *cli) Run(args []string) int {
 					if res == "y" {
 						switch strings.ToLower(cmdArgs[1]) {
 						case "server":
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
 								// stop database server
 								if forceFlag {
 									graceTime = 0
 								}
-								exitStatus, _ = stopDatabaseServer(u, token, message, graceTime)
+								exitStatus, _ = stopDatabaseServer(client, message, graceTime)
 								if exitStatus == 0 {
-									_, _ = waitStoppingServer(u, token)
+									_, _ = waitStoppingServer(client)
 									// start database server
-									exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{status: "RUNNING"})
+									exitStatus = getExitStatus(client.SetServerStatus("RUNNING"))
+								} else if exitStatus == dryRunStatus {
+									// print the request to start the server as well
+									exitStatus = getExitStatus(client.SetServerStatus("RUNNING"))
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -1573,29 +1975,32 @@ func (c *cli) Run(args []string) int {
 				}
 			}
 		case "resume":
-			token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+			token, exitStatus, err = login(client, username, password, loginParams)
 			if token != "" && exitStatus == 0 && err == nil {
-				u.Path = path.Join(getAPIBasePath(), "databases")
 				args = []string{""}
 				if len(cmdArgs[1:]) > 0 {
 					args = cmdArgs[1:]
 				}
-				idList, nameList, _ := getDatabases(u.String(), token, args, "PAUSED", false)
+				idList, nameList, _ := selectDatabases(client, args, "PAUSED", false, selector)
 				if len(idList) > 0 {
 					for i := 0; i < len(idList); i++ {
 						fmt.Fprintln(c.outStream, "File Resuming: "+nameList[i])
 					}
+					results := make([]int, len(idList))
 					for i := 0; i < len(idList); i++ {
-						u.Path = path.Join(getAPIBasePath(), "databases", strconv.Itoa(idList[i]))
-						exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{command: "resume"})
-						if exitStatus == 0 && err == nil {
+						exitStatus = getExitStatus(client.ResumeDatabase(idList[i]))
+						results[i] = exitStatus
+						if exitStatus == 0 {
 							fmt.Fprintln(c.outStream, "File Resumed: "+nameList[i])
 						}
 					}
+					if waitFlag {
+						exitStatus = waitForDatabases(c, client, idList, nameList, results, "PAUSED", "NORMAL", waitTimeout)
+					}
 				} else {
 					exitStatus = 10904
 				}
-				logout(baseURI, token)
+				logout(client)
 			} else if detectHostUnreachable(exitStatus) {
 				exitStatus = 10502
 			}
@@ -1603,7 +2008,7 @@ func (c *cli) Run(args []string) int {
 			if len(cmdArgs[1:]) > 0 {
 				switch strings.ToLower(cmdArgs[1]) {
 				case "schedule":
-					token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+					token, exitStatus, err = login(client, username, password, loginParams)
 					if token != "" && exitStatus == 0 && err == nil {
 						id := 0
 						if len(cmdArgs) >= 3 {
@@ -1613,11 +2018,9 @@ func (c *cli) Run(args []string) int {
 							}
 						}
 						if id > 0 {
-							u.Path = path.Join(getAPIBasePath(), "schedules", strconv.Itoa(id))
-							exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{status: "RUNNING"})
-							if exitStatus == 0 && err == nil {
-								u.Path = path.Join(getAPIBasePath(), "schedules", strconv.Itoa(id))
-								scheduleName := getScheduleName(u.String(), token, id)
+							exitStatus = getExitStatus(client.RunSchedule(id))
+							if exitStatus == 0 {
+								scheduleName := getScheduleName(client, id)
 								if scheduleName != "" {
 									fmt.Fprintln(c.outStream, "Schedule '"+scheduleName+"' will run now.")
 								} else {
@@ -1629,7 +2032,7 @@ func (c *cli) Run(args []string) int {
 						} else {
 							exitStatus = 10600
 						}
-						logout(baseURI, token)
+						logout(client)
 					} else if detectHostUnreachable(exitStatus) {
 						exitStatus = 10502
 					}
@@ -1640,10 +2043,25 @@ func (c *cli) Run(args []string) int {
 				exitStatus = outputInvalidCommandErrorMessage(c)
 			}
 		case "send":
-			token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+			var filter []clientCondition
+			if cFlags.whereFlag {
+				filter, err = parseClientFilter(cFlags.where)
+				if err != nil {
+					fmt.Fprintln(c.outStream, "Invalid parameter for option: --where ("+err.Error()+")")
+					exitStatus = 10001
+				} else if clientID > -1 {
+					fmt.Fprintln(c.outStream, "--client and --where cannot be used together")
+					exitStatus = 10001
+				}
+				if exitStatus != 0 {
+					break
+				}
+			}
+
+			token, exitStatus, err = login(client, username, password, loginParams)
 			if token != "" && exitStatus == 0 && err == nil {
-				exitStatus = sendMessages(u, token, message, cmdArgs, clientID)
-				logout(baseURI, token)
+				exitStatus = sendMessages(c, client, message, cmdArgs, clientID, filter)
+				logout(client)
 			} else if detectHostUnreachable(exitStatus) {
 				exitStatus = 10502
 			}
@@ -1676,17 +2094,16 @@ func (c *cli) Run(args []string) int {
 							}
 
 							if exitStatus == 0 {
-								token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+								token, exitStatus, err = login(client, username, password, loginParams)
 								if token != "" && exitStatus == 0 && err == nil {
-									u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-									version := getServerVersion(u.String(), token)
-									if runtime.GOOS == "linux" && fqdn == "" && version < 19.6 {
+									version := getServerVersion(client)
+									if runtime.GOOS == "linux" && localServer && version < 19.6 {
 										// Not Supported
 										exitStatus = 10001
 									} else {
 										var settings []string
 										printOptions := []string{}
-										settings, exitStatus, err = getWebTechnologyConfigurations(baseURI, getAPIBasePath(), token, printOptions)
+										settings, exitStatus, err = getWebTechnologyConfigurations(c, client, printOptions)
 										if err == nil {
 											var results []string
 											results, exitStatus = parseWebConfigurationSettings(cmdArgs[2:])
@@ -1821,21 +2238,19 @@ func (c *cli) Run(args []string) int {
 													useFMPHP = false
 												}
 
-												u.Path = path.Join(getAPIBasePath(), "php", "config")
 												if settings[4] != "" {
 													// exclude Claris FileMaker Server for Linux
-													exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{
-														command:              "set",
-														enabled:              phpEnabled,
-														characterencoding:    encoding,
-														errormessagelanguage: locale,
-														dataprevalidation:    preValidation,
-														usefilemakerphp:      useFMPHP,
-													})
+													exitStatus = getExitStatus(client.SetPHPConfig(fmsadmin.PHPConfig{
+														Enabled:              phpEnabled != "false",
+														CharacterEncoding:    encoding,
+														ErrorMessageLanguage: locale,
+														DataPreValidation:    preValidation,
+														UseFileMakerPhp:      useFMPHP,
+													}))
 												}
 											}
 
-											if exitStatus == 0 {
+											if exitStatus == 0 || exitStatus == dryRunStatus {
 												if strings.ToLower(xmlFlag) == "true" || strings.ToLower(xmlFlag) == "false" {
 													if strings.ToLower(xmlFlag) == "true" {
 														xmlEnabled = "true"
@@ -1847,18 +2262,19 @@ func (c *cli) Run(args []string) int {
 														xmlEnabled = "false"
 													}
 
-													u.Path = path.Join(getAPIBasePath(), "xml", "config")
-													_, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", enabled: xmlEnabled})
+													_ = client.SetXMLConfig(fmsadmin.XMLConfig{Enabled: xmlEnabled != "false"})
 												}
 
-												_, exitStatus, _ = getWebTechnologyConfigurations(baseURI, getAPIBasePath(), token, printOptions)
-												if restartMessageFlag {
-													fmt.Fprintln(c.outStream, "Restart the FileMaker Server background processes to apply the change.")
+												if !dryRunFlag {
+													_, exitStatus, _ = getWebTechnologyConfigurations(c, client, printOptions)
+													if restartMessageFlag {
+														fmt.Fprintln(c.outStream, "Restart the FileMaker Server background processes to apply the change.")
+													}
 												}
 											}
 										}
 									}
-									logout(baseURI, token)
+									logout(client)
 								} else if detectHostUnreachable(exitStatus) {
 									exitStatus = 10502
 								}
@@ -1895,12 +2311,11 @@ func (c *cli) Run(args []string) int {
 						}
 
 						if exitStatus == 0 {
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
 								var settings []int
 								printOptions := []string{}
-								u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-								settings, exitStatus = getServerGeneralConfigurations(u.String(), token, printOptions)
+								settings, exitStatus = getServerGeneralConfigurations(c, client, printOptions)
 								if exitStatus == 0 {
 									var results []string
 									results, exitStatus = parseServerConfigurationSettings(cmdArgs[2:])
@@ -1925,8 +2340,7 @@ func (c *cli) Run(args []string) int {
 										if results[1] == "" {
 											maxFiles = settings[1]
 										} else {
-											u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-											version := getServerVersion(u.String(), token)
+											version := getServerVersion(client)
 											if version >= 20.1 {
 												if maxFiles < 1 || maxFiles > 256 {
 													exitStatus = 10001
@@ -2004,30 +2418,31 @@ func (c *cli) Run(args []string) int {
 										}
 										if exitStatus == 0 {
 											if results[0] != "" || results[1] != "" || results[2] != "" || results[3] != "" {
-												u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-												exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{
-													command:                   "set",
-													cachesize:                 cacheSize,
-													maxfiles:                  maxFiles,
-													maxproconnections:         maxProConnections,
-													maxpsos:                   maxPSOS,
-													startuprestorationbuiltin: startupRestorationBuiltin,
-												})
+												generalConfig := fmsadmin.GeneralConfig{
+													CacheSize:         cacheSize,
+													MaxFiles:          maxFiles,
+													MaxProConnections: maxProConnections,
+													MaxPSOS:           maxPSOS,
+												}
+												if startupRestorationBuiltin {
+													// for Claris FileMaker Server 19.1.1 or previous
+													startupRestoration := false
+													generalConfig.StartupRestorationEnabled = &startupRestoration
+												}
+												exitStatus = getExitStatus(client.SetGeneralConfig(generalConfig))
 											}
 
-											if exitStatus == 0 && (secureFilesOnlyFlag == "true" || secureFilesOnlyFlag == "false") {
-												u.Path = path.Join(getAPIBasePath(), "server", "config", "security")
-												exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", requiresecuredb: secureFilesOnlyFlag})
+											if (exitStatus == 0 || exitStatus == dryRunStatus) && (secureFilesOnlyFlag == "true" || secureFilesOnlyFlag == "false") {
+												exitStatus = getExitStatus(client.SetSecurityConfig(fmsadmin.SecurityConfig{RequireSecureDB: secureFilesOnlyFlag != "false"}))
 											}
 
 											if exitStatus == 0 {
-												u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-												_, exitStatus = getServerGeneralConfigurations(u.String(), token, printOptions)
+												_, exitStatus = getServerGeneralConfigurations(c, client, printOptions)
 											}
 										}
 									}
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -2063,14 +2478,13 @@ func (c *cli) Run(args []string) int {
 					}
 
 					if exitStatus == 0 {
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
 							var versionString string
 							var version float64
 
 							if !usingCloud {
-								u.Path = path.Join(getAPIBasePath(), "server", "metadata")
-								versionString, _ = getServerVersionString(u.String(), token)
+								versionString, _ = getServerVersionString(client)
 								version, _ = getServerVersionAsFloat(versionString)
 							}
 
@@ -2111,18 +2525,16 @@ func (c *cli) Run(args []string) int {
 									}
 								}
 								if exitStatus == 0 {
-									u.Path = path.Join(getAPIBasePath(), "server", "config", "authenticatedstream")
-									exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", authenticatedstream: authenticatedStream})
-									if exitStatus != 0 {
+									exitStatus = getExitStatus(client.SetAuthenticatedStreamConfig(fmsadmin.AuthenticatedStreamConfig{AuthenticatedStream: authenticatedStream}))
+									if exitStatus == 0 {
+										_, exitStatus, _ = getAuthenticatedStreamSetting(c, client, printOptions)
+									} else if exitStatus != dryRunStatus {
 										exitStatus = 10001
-									} else {
-										_, exitStatus, _ = getAuthenticatedStreamSetting(u.String(), token, printOptions)
 									}
 								}
 							} else {
 								// for Claris FileMaker Server
-								u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-								settings, exitStatus = getServerGeneralConfigurations(u.String(), token, printOptions)
+								settings, exitStatus = getServerGeneralConfigurations(c, client, printOptions)
 								if exitStatus == 0 {
 									var results []string
 									results, exitStatus = parseServerConfigurationSettings(cmdArgs[2:])
@@ -2307,31 +2719,33 @@ func (c *cli) Run(args []string) int {
 										}
 										if exitStatus == 0 {
 											if results[0] != "" || results[1] != "" || results[2] != "" || results[3] != "" || results[4] != "" || results[13] != "" {
-												u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-												exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{
-													command:                     "set",
-													cachesize:                   cacheSize,
-													maxfiles:                    maxFiles,
-													maxproconnections:           maxProConnections,
-													maxpsos:                     maxPSOS,
-													startuprestorationenabled:   startupRestoration,
-													startuprestorationbuiltin:   startupRestorationBuiltin,
-													onlyopenlastopeneddatabases: onlyOpenLastOpenedDatabases,
-												})
+												generalConfig := fmsadmin.GeneralConfig{
+													CacheSize:         cacheSize,
+													MaxFiles:          maxFiles,
+													MaxProConnections: maxProConnections,
+													MaxPSOS:           maxPSOS,
+												}
+												if onlyOpenLastOpenedDatabases != "" {
+													// for Claris FileMaker Server 21.1.1 or later
+													onlyOpenLastOpened := onlyOpenLastOpenedDatabases == "true"
+													generalConfig.OnlyOpenLastOpenedDatabases = &onlyOpenLastOpened
+												} else if startupRestorationBuiltin {
+													// for Claris FileMaker Server 19.1.1 or previous
+													generalConfig.StartupRestorationEnabled = &startupRestoration
+												}
+												exitStatus = getExitStatus(client.SetGeneralConfig(generalConfig))
 											}
 
-											if exitStatus == 0 && (secureFilesOnlyFlag == "true" || secureFilesOnlyFlag == "false") {
-												u.Path = path.Join(getAPIBasePath(), "server", "config", "security")
-												exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", requiresecuredb: secureFilesOnlyFlag})
+											if (exitStatus == 0 || exitStatus == dryRunStatus) && (secureFilesOnlyFlag == "true" || secureFilesOnlyFlag == "false") {
+												exitStatus = getExitStatus(client.SetSecurityConfig(fmsadmin.SecurityConfig{RequireSecureDB: secureFilesOnlyFlag != "false"}))
 											}
 
-											if exitStatus == 0 {
+											if exitStatus == 0 || exitStatus == dryRunStatus {
 												if results[6] != "" {
 													if version >= 19.3 && !strings.HasPrefix(versionString, "19.3.1") {
 														// for Claris FileMaker Server 19.3.2 or later
-														u.Path = path.Join(getAPIBasePath(), "server", "config", "authenticatedstream")
-														exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", authenticatedstream: authenticatedStream})
-														if exitStatus != 0 {
+														exitStatus = getExitStatus(client.SetAuthenticatedStreamConfig(fmsadmin.AuthenticatedStreamConfig{AuthenticatedStream: authenticatedStream}))
+														if exitStatus != 0 && exitStatus != dryRunStatus {
 															exitStatus = 10001
 														}
 													} else {
@@ -2342,9 +2756,8 @@ func (c *cli) Run(args []string) int {
 												if results[7] != "" {
 													// for Claris FileMaker Server 19.5.1 or later
 													if version >= 19.5 {
-														u.Path = path.Join(getAPIBasePath(), "server", "config", "parallelbackup")
-														exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", parallelbackupenabled: parallelBackupEnabled})
-														if exitStatus != 0 {
+														exitStatus = getExitStatus(client.SetParallelBackupConfig(fmsadmin.ParallelBackupConfig{ParallelBackupEnabled: parallelBackupEnabled == "true"}))
+														if exitStatus != 0 && exitStatus != dryRunStatus {
 															exitStatus = 10001
 														}
 													} else {
@@ -2359,9 +2772,7 @@ func (c *cli) Run(args []string) int {
 													if version >= 21.0 {
 														var persistentCacheSettings []string
 
-														u.Path = path.Join(getAPIBasePath(), "server", "config", "persistentcache")
-
-														persistentCacheSettings, exitStatus, _ = getPersistentCacheConfigurations(u.String(), token, noPrintOptions)
+														persistentCacheSettings, exitStatus, _ = getPersistentCacheConfigurations(c, client, noPrintOptions)
 														if exitStatus == 0 {
 															if persistCacheEnabled == "" {
 																persistCacheEnabled = persistentCacheSettings[0]
@@ -2383,7 +2794,12 @@ func (c *cli) Run(args []string) int {
 																needToRestartFlag = true
 															}
 
-															exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", persistcacheenabled: persistCacheEnabled, syncpersistcache: syncPersistCache, databaseserverautorestart: databaseServerAutoRestart})
+															exitStatus = getExitStatus(client.SetPersistentCacheConfig(fmsadmin.PersistentCacheConfig{
+																PersistentCache: persistCacheEnabled == "true",
+																// the value of PersistCacheEnabled must be true
+																PersistentCacheSync:       syncPersistCache == "true",
+																DatabaseServerAutoRestart: databaseServerAutoRestart == "true",
+															}))
 
 															if exitStatus == 0 {
 																for _, option := range printOptions {
@@ -2395,7 +2811,7 @@ func (c *cli) Run(args []string) int {
 															}
 														}
 
-														if exitStatus != 0 {
+														if exitStatus != 0 && exitStatus != dryRunStatus {
 															exitStatus = 10001
 														}
 													} else {
@@ -2406,9 +2822,8 @@ func (c *cli) Run(args []string) int {
 												if results[11] != "" {
 													// for Claris FileMaker Server 21.0.1 or later
 													if version >= 21.0 {
-														u.Path = path.Join(getAPIBasePath(), "server", "config", "blocknewusers")
-														exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", blocknewusersenabled: blockNewUsersEnabled})
-														if exitStatus != 0 {
+														exitStatus = getExitStatus(client.SetBlockNewUsersConfig(fmsadmin.BlockNewUsersConfig{BlockNewUsers: blockNewUsersEnabled == "true"}))
+														if exitStatus != 0 && exitStatus != dryRunStatus {
 															exitStatus = 10001
 														}
 													} else {
@@ -2419,9 +2834,8 @@ func (c *cli) Run(args []string) int {
 												if results[12] != "" {
 													// for Claris FileMaker Server 21.1.1 or later
 													if version >= 21.1 {
-														u.Path = path.Join(getAPIBasePath(), "fmclients", "httpstunneling")
-														exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{command: "set", enablehttpprotocolnetwork: enableHttpProtocolNetwork})
-														if exitStatus != 0 {
+														exitStatus = getExitStatus(client.SetHTTPSTunnelingConfig(fmsadmin.HTTPSTunnelingConfig{EnableHTTPSTunneling: enableHttpProtocolNetwork == "true"}))
+														if exitStatus != 0 && exitStatus != dryRunStatus {
 															exitStatus = 10001
 														}
 													} else {
@@ -2429,14 +2843,15 @@ func (c *cli) Run(args []string) int {
 													}
 												}
 
-												u.Path = path.Join(getAPIBasePath(), "server", "config", "general")
-												settingResults, exitStatus = getServerGeneralConfigurations(u.String(), token, printOptions)
-												if restartMessageFlag {
-													fmt.Fprintln(c.outStream, "Please restart the FileMaker Server service to apply the change.")
-												}
-												if startupRestorationBuiltin && settings[4] != settingResults[4] {
-													// check setting of startupRestorationEnabled
-													fmt.Println("Restart the FileMaker Server background processes to apply the change.")
+												if !dryRunFlag {
+													settingResults, exitStatus = getServerGeneralConfigurations(c, client, printOptions)
+													if restartMessageFlag {
+														fmt.Fprintln(c.outStream, "Please restart the FileMaker Server service to apply the change.")
+													}
+													if startupRestorationBuiltin && settings[4] != settingResults[4] {
+														// check setting of startupRestorationEnabled
+														fmt.Println("Restart the FileMaker Server background processes to apply the change.")
+													}
 												}
 											}
 										}
@@ -2444,7 +2859,7 @@ func (c *cli) Run(args []string) int {
 								}
 							}
 
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -2462,19 +2877,17 @@ func (c *cli) Run(args []string) int {
 				if len(cmdArgs[1:]) > 0 {
 					switch strings.ToLower(cmdArgs[1]) {
 					case "server":
-						token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+						token, exitStatus, err = login(client, username, password, loginParams)
 						if token != "" && exitStatus == 0 && err == nil {
-							var running string
-							u.Path = path.Join(getAPIBasePath(), "server", "status")
-							_, running, _ = sendRequest("GET", u.String(), token, params{})
+							running, _ := client.ServerStatus()
 							if running == "RUNNING" {
 								// Service already running
 								exitStatus = 10006
 							} else {
 								// start database server
-								exitStatus, _, _ = sendRequest("PATCH", u.String(), token, params{status: "RUNNING"})
+								exitStatus = getExitStatus(client.SetServerStatus("RUNNING"))
 							}
-							logout(baseURI, token)
+							logout(client)
 						} else if detectHostUnreachable(exitStatus) {
 							exitStatus = 10502
 						}
@@ -2489,7 +2902,7 @@ func (c *cli) Run(args []string) int {
 			if len(cmdArgs[1:]) > 0 {
 				switch strings.ToLower(cmdArgs[1]) {
 				case "client":
-					token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+					token, exitStatus, err = login(client, username, password, loginParams)
 					if token != "" && exitStatus == 0 && err == nil {
 						id := 0
 						if len(cmdArgs) >= 3 {
@@ -2499,26 +2912,28 @@ func (c *cli) Run(args []string) int {
 							}
 						}
 						if id > 0 {
-							u.Path = path.Join(getAPIBasePath(), "clients")
-							exitStatus = listClients(u.String(), token, id)
+							exitStatus = listClients(c, client, id)
 						}
-						logout(baseURI, token)
+						logout(client)
 					} else if detectHostUnreachable(exitStatus) {
 						exitStatus = 10502
 					}
 				case "file":
-					token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+					token, exitStatus, err = login(client, username, password, loginParams)
 					if token != "" && exitStatus == 0 && err == nil {
-						if len(cmdArgs[2:]) > 0 {
-							u.Path = path.Join(getAPIBasePath(), "databases")
-							idList, _, _ := getDatabases(u.String(), token, cmdArgs[2:], "", false)
+						if len(cmdArgs[2:]) > 0 || selector.isSet() {
+							args = []string{""}
+							if len(cmdArgs[2:]) > 0 {
+								args = cmdArgs[2:]
+							}
+							idList, _, _ := selectDatabases(client, args, "", false, selector)
 							if len(idList) > 0 {
-								exitStatus = listFiles(c, u.String(), token, idList)
+								exitStatus = listFiles(c, client, idList)
 							}
 						} else {
 							exitStatus = 10001
 						}
-						logout(baseURI, token)
+						logout(client)
 					} else if detectHostUnreachable(exitStatus) {
 						exitStatus = 10502
 					}
@@ -2545,18 +2960,18 @@ func (c *cli) Run(args []string) int {
 					if res == "y" {
 						switch strings.ToLower(cmdArgs[1]) {
 						case "server":
-							token, exitStatus, err = login(baseURI, username, password, params{retry: retry, identityFile: identityFile})
+							token, exitStatus, err = login(client, username, password, loginParams)
 							if token != "" && exitStatus == 0 && err == nil {
 								message = "Stopping FileMaker Database Engine..."
 								// message = "FileMaker データベースエンジンの停止中..."
 								if forceFlag {
 									graceTime = 0
 								}
-								exitStatus, _ = stopDatabaseServer(u, token, message, graceTime)
+								exitStatus, _ = stopDatabaseServer(client, message, graceTime)
 								if exitStatus == 0 {
-									exitStatus, _ = waitStoppingServer(u, token)
+									exitStatus, _ = waitStoppingServer(client)
 								}
-								logout(baseURI, token)
+								logout(client)
 							} else if detectHostUnreachable(exitStatus) {
 								exitStatus = 10502
 							}
@@ -2583,6 +2998,20 @@ func (c *cli) Run(args []string) int {
 		}
 	}
 
+	if c.settings != nil {
+		if c.format != "" {
+			if result := outputTemplate(c, "{{template \"format\" .}}\n", c.settings); exitStatus == 0 {
+				exitStatus = result
+			}
+		} else if c.output == "json" {
+			outputJSON(c, c.settings)
+		}
+	}
+
+	if dryRunFlag && exitStatus == dryRunStatus {
+		exitStatus = 0
+	}
+
 	if exitStatus != 0 && exitStatus != 23 && exitStatus != 248 && exitStatus != 249 {
 		outputErrorMessage(exitStatus, c)
 	}
@@ -2598,6 +3027,7 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 	statsFlag := false
 	forceFlag := false
 	saveKeyFlag := false
+	noHeadersFlag := false
 	fqdn := ""
 	hostname := ""
 	username := ""
@@ -2610,6 +3040,37 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 	clientID := -1
 	graceTime := 90
 	identityFile := ""
+	output := ""
+	format := ""
+	profileName := ""
+	credentialHelper := ""
+	timeout := -1
+	retries := -1
+	caCert := ""
+	cert := ""
+	certKey := ""
+	pinSHA256 := ""
+	insecureFlag := false
+	serverURL := ""
+	proxy := ""
+	sshJump := ""
+	keyName := ""
+	tokenLifetime := ""
+	signerCommand := ""
+	passwordFile := ""
+	passwordStdin := false
+	keyFileForDB := ""
+	keyFilePassFile := ""
+	keyMap := ""
+	waitFlag := false
+	waitTimeout := ""
+	match := ""
+	exclude := ""
+	dryRunFlag := false
+	drainFlag := false
+	drainTimeout := ""
+	drainReminder := ""
+	where := ""
 
 	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
 	flags.Usage = func() {}
@@ -2642,9 +3103,42 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 	flags.BoolVar(&forceFlag, "f", false, "Force database to close or Database Server to stop, immediately disconnecting clients.")
 	flags.BoolVar(&forceFlag, "force", false, "Force database to close or Database Server to stop, immediately disconnecting clients.")
 	flags.BoolVar(&saveKeyFlag, "savekey", false, "Save the database encryption password.")
+	flags.BoolVar(&noHeadersFlag, "no-headers", false, "Do not print the header row of tables.")
 	flags.IntVar(&graceTime, "t", 90, "Specify time in seconds before client is forced to disconnect.")
 	flags.IntVar(&graceTime, "gracetime", 90, "Specify time in seconds before client is forced to disconnect.")
 	flags.StringVar(&identityFile, "i", "", "Specify a private key file for FileMaker Admin API PKI Authentication.")
+	flags.StringVar(&output, "o", "", "Specify the output format.")
+	flags.StringVar(&output, "output", "", "Specify the output format.")
+	flags.StringVar(&format, "format", "", "Specify the Go template to format the output.")
+	flags.StringVar(&profileName, "profile", "", "Specify the profile in the configuration file.")
+	flags.StringVar(&credentialHelper, "credential-helper", "", "Specify the credential helper command.")
+	flags.IntVar(&timeout, "timeout", -1, "Specify the time limit of a request in seconds.")
+	flags.IntVar(&retries, "retries", -1, "Specify the number of retries of a failed request.")
+	flags.StringVar(&caCert, "cacert", "", "Specify the CA certificates to verify the server certificate.")
+	flags.StringVar(&cert, "cert", "", "Specify the client certificate for TLS.")
+	flags.StringVar(&certKey, "cert-key", "", "Specify the private key of the client certificate for TLS.")
+	flags.StringVar(&pinSHA256, "pin-sha256", "", "Specify the SHA-256 hash of the public key of the server certificate.")
+	flags.BoolVar(&insecureFlag, "insecure", false, "Skip the verification of the server certificate.")
+	flags.StringVar(&serverURL, "url", "", "Specify the URL of the server.")
+	flags.StringVar(&proxy, "proxy", "", "Specify the proxy to connect to the server.")
+	flags.StringVar(&sshJump, "ssh-jump", "", "Specify the SSH bastion host to connect to the server.")
+	flags.StringVar(&keyName, "key-name", "", "Specify the name of the public key on Admin Console.")
+	flags.StringVar(&tokenLifetime, "token-lifetime", "", "Specify the lifetime of the JSON Web Token for PKI authentication.")
+	flags.StringVar(&signerCommand, "signer-command", "", "Specify a command to sign the JSON Web Token for PKI authentication.")
+	flags.StringVar(&passwordFile, "password-file", "", "Read the password from the file.")
+	flags.BoolVar(&passwordStdin, "password-stdin", false, "Read the password from the standard input.")
+	flags.StringVar(&keyFileForDB, "key-file-for-db", "", "Read the database encryption password from the file.")
+	flags.StringVar(&keyFilePassFile, "keyfilepass-file", "", "Read the password needed to read KEYFILE from the file.")
+	flags.StringVar(&keyMap, "keymap", "", "Specify the JSON file of the encryption passwords of databases.")
+	flags.BoolVar(&waitFlag, "wait", false, "Wait until the databases reach the final status.")
+	flags.StringVar(&waitTimeout, "wait-timeout", "", "Specify the time limit of waiting for the databases.")
+	flags.StringVar(&match, "match", "", "Select databases whose file names match the regular expression.")
+	flags.StringVar(&exclude, "exclude", "", "Exclude databases whose file names match the pattern.")
+	flags.BoolVar(&dryRunFlag, "dry-run", false, "Print the requests that change the server without sending them.")
+	flags.BoolVar(&drainFlag, "drain", false, "Close databases after their clients disconnect.")
+	flags.StringVar(&drainTimeout, "drain-timeout", "", "Specify the time limit of draining clients.")
+	flags.StringVar(&drainReminder, "drain-reminder", "", "Specify the interval of the reminder messages while draining clients.")
+	flags.StringVar(&where, "where", "", "Select clients matching the conditions.")
 
 	buf := &bytes.Buffer{}
 	flags.SetOutput(buf)
@@ -2663,6 +3157,7 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 	cFlags.statsFlag = cFlags.statsFlag || statsFlag
 	cFlags.forceFlag = cFlags.forceFlag || forceFlag
 	cFlags.saveKeyFlag = cFlags.saveKeyFlag || saveKeyFlag
+	cFlags.noHeadersFlag = cFlags.noHeadersFlag || noHeadersFlag
 	if cFlags.fqdn == "" {
 		cFlags.fqdn = fqdn
 	}
@@ -2699,6 +3194,93 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 	if cFlags.identityFile == "" {
 		cFlags.identityFile = identityFile
 	}
+	if cFlags.output == "" {
+		cFlags.output = output
+	}
+	if cFlags.format == "" {
+		cFlags.format = format
+	}
+	if cFlags.profile == "" {
+		cFlags.profile = profileName
+	}
+	if cFlags.credentialHelper == "" {
+		cFlags.credentialHelper = credentialHelper
+	}
+	if cFlags.timeout == -1 {
+		cFlags.timeout = timeout
+	}
+	if cFlags.retries == -1 {
+		cFlags.retries = retries
+	}
+	if cFlags.caCert == "" {
+		cFlags.caCert = caCert
+	}
+	if cFlags.cert == "" {
+		cFlags.cert = cert
+	}
+	if cFlags.certKey == "" {
+		cFlags.certKey = certKey
+	}
+	if cFlags.pinSHA256 == "" {
+		cFlags.pinSHA256 = pinSHA256
+	}
+	cFlags.insecureFlag = cFlags.insecureFlag || insecureFlag
+	if cFlags.serverURL == "" {
+		cFlags.serverURL = serverURL
+	}
+	if cFlags.proxy == "" {
+		cFlags.proxy = proxy
+	}
+	if cFlags.sshJump == "" {
+		cFlags.sshJump = sshJump
+	}
+	if cFlags.keyName == "" {
+		cFlags.keyName = keyName
+	}
+	if cFlags.tokenLifetime == "" {
+		cFlags.tokenLifetime = tokenLifetime
+	}
+	if cFlags.signerCommand == "" {
+		cFlags.signerCommand = signerCommand
+	}
+	if cFlags.passwordFile == "" {
+		cFlags.passwordFile = passwordFile
+	}
+	cFlags.passwordStdin = cFlags.passwordStdin || passwordStdin
+	if cFlags.keyFileForDB == "" {
+		cFlags.keyFileForDB = keyFileForDB
+	}
+	if cFlags.keyFilePassFile == "" {
+		cFlags.keyFilePassFile = keyFilePassFile
+	}
+	if cFlags.keyMap == "" {
+		cFlags.keyMap = keyMap
+	}
+	cFlags.waitFlag = cFlags.waitFlag || waitFlag
+	if cFlags.waitTimeout == "" {
+		cFlags.waitTimeout = waitTimeout
+	}
+	if cFlags.match == "" {
+		cFlags.match = match
+	}
+	if cFlags.exclude == "" {
+		cFlags.exclude = exclude
+	}
+	cFlags.dryRunFlag = cFlags.dryRunFlag || dryRunFlag
+	cFlags.drainFlag = cFlags.drainFlag || drainFlag
+	if cFlags.drainTimeout == "" {
+		cFlags.drainTimeout = drainTimeout
+	}
+	if cFlags.drainReminder == "" {
+		cFlags.drainReminder = drainReminder
+	}
+	if cFlags.where == "" {
+		cFlags.where = where
+	}
+	flags.Visit(func(f *flag.Flag) {
+		// --where with an empty expression is an error
+		cFlags.whereFlag = cFlags.whereFlag || f.Name == "where"
+	})
 
 	cmdArgs = flags.Args()
 
@@ -2721,6 +3303,7 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 		cFlags.statsFlag = cFlags.statsFlag || subCommandOptions.statsFlag
 		cFlags.forceFlag = cFlags.forceFlag || subCommandOptions.forceFlag
 		cFlags.saveKeyFlag = cFlags.saveKeyFlag || subCommandOptions.saveKeyFlag
+		cFlags.noHeadersFlag = cFlags.noHeadersFlag || subCommandOptions.noHeadersFlag
 		if cFlags.fqdn == "" {
 			cFlags.fqdn = subCommandOptions.fqdn
 		}
@@ -2757,6 +3340,90 @@ func getFlags(args []string, cFlags commandOptions) ([]string, commandOptions, e
 		if cFlags.identityFile == "" {
 			cFlags.identityFile = subCommandOptions.identityFile
 		}
+		if cFlags.output == "" {
+			cFlags.output = subCommandOptions.output
+		}
+		if cFlags.format == "" {
+			cFlags.format = subCommandOptions.format
+		}
+		if cFlags.profile == "" {
+			cFlags.profile = subCommandOptions.profile
+		}
+		if cFlags.credentialHelper == "" {
+			cFlags.credentialHelper = subCommandOptions.credentialHelper
+		}
+		if cFlags.timeout == -1 {
+			cFlags.timeout = subCommandOptions.timeout
+		}
+		if cFlags.retries == -1 {
+			cFlags.retries = subCommandOptions.retries
+		}
+		if cFlags.caCert == "" {
+			cFlags.caCert = subCommandOptions.caCert
+		}
+		if cFlags.cert == "" {
+			cFlags.cert = subCommandOptions.cert
+		}
+		if cFlags.certKey == "" {
+			cFlags.certKey = subCommandOptions.certKey
+		}
+		if cFlags.pinSHA256 == "" {
+			cFlags.pinSHA256 = subCommandOptions.pinSHA256
+		}
+		cFlags.insecureFlag = cFlags.insecureFlag || subCommandOptions.insecureFlag
+		if cFlags.serverURL == "" {
+			cFlags.serverURL = subCommandOptions.serverURL
+		}
+		if cFlags.proxy == "" {
+			cFlags.proxy = subCommandOptions.proxy
+		}
+		if cFlags.sshJump == "" {
+			cFlags.sshJump = subCommandOptions.sshJump
+		}
+		if cFlags.keyName == "" {
+			cFlags.keyName = subCommandOptions.keyName
+		}
+		if cFlags.tokenLifetime == "" {
+			cFlags.tokenLifetime = subCommandOptions.tokenLifetime
+		}
+		if cFlags.signerCommand == "" {
+			cFlags.signerCommand = subCommandOptions.signerCommand
+		}
+		if cFlags.passwordFile == "" {
+			cFlags.passwordFile = subCommandOptions.passwordFile
+		}
+		cFlags.passwordStdin = cFlags.passwordStdin || subCommandOptions.passwordStdin
+		if cFlags.keyFileForDB == "" {
+			cFlags.keyFileForDB = subCommandOptions.keyFileForDB
+		}
+		if cFlags.keyFilePassFile == "" {
+			cFlags.keyFilePassFile = subCommandOptions.keyFilePassFile
+		}
+		if cFlags.keyMap == "" {
+			cFlags.keyMap = subCommandOptions.keyMap
+		}
+		cFlags.waitFlag = cFlags.waitFlag || subCommandOptions.waitFlag
+		if cFlags.waitTimeout == "" {
+			cFlags.waitTimeout = subCommandOptions.waitTimeout
+		}
+		if cFlags.match == "" {
+			cFlags.match = subCommandOptions.match
+		}
+		if cFlags.exclude == "" {
+			cFlags.exclude = subCommandOptions.exclude
+		}
+		cFlags.dryRunFlag = cFlags.dryRunFlag || subCommandOptions.dryRunFlag
+		cFlags.drainFlag = cFlags.drainFlag || subCommandOptions.drainFlag
+		if cFlags.drainTimeout == "" {
+			cFlags.drainTimeout = subCommandOptions.drainTimeout
+		}
+		if cFlags.drainReminder == "" {
+			cFlags.drainReminder = subCommandOptions.drainReminder
+		}
+		if cFlags.where == "" {
+			cFlags.where = subCommandOptions.where
+		}
+		cFlags.whereFlag = cFlags.whereFlag || subCommandOptions.whereFlag
 	}
 
 	return resultArgs, cFlags, nil
@@ -3017,13 +3684,33 @@ func getBaseURI(fqdn string) string {
 	return baseURI
 }
 
-func getAPIBasePath() string {
-	path := "/fmi/admin/api/v2"
+// parseServerURL returns the base URL of the server specified with the URL
+// option (e.g. "https://proxy.example.jp:8443/fms-admin").
+func parseServerURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid server URL: %s", rawURL)
+	}
+
+	// FileMaker Admin API is appended to the path prefix
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if strings.HasSuffix(u.Path, getAPIBasePath()) {
+		u.Path = strings.TrimSuffix(u.Path, getAPIBasePath())
+	}
+	u.RawPath = ""
+
+	return u.String(), nil
+}
 
-	return path
+func getAPIBasePath() string {
+	return fmsadmin.BasePath
 }
 
-func getUsernameAndPassword(username string, password string, product int) (string, string) {
+func getUsernameAndPassword(username string, password string, product int, passwordCommand string) (string, string) {
 	if len(username) == 0 {
 		if product == 1 {
 			username = os.Getenv("FMS_USERNAME")
@@ -3038,6 +3725,15 @@ func getUsernameAndPassword(username string, password string, product int) (stri
 		}
 	}
 
+	if len(password) == 0 && len(passwordCommand) > 0 {
+		// for "password_command" of the profile
+		var err error
+		password, err = runPasswordCommand(passwordCommand)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "fmcsadmin: password command failed: "+err.Error())
+		}
+	}
+
 	if len(password) == 0 {
 		if product == 1 {
 			password = os.Getenv("FMS_PASSWORD")
@@ -3055,65 +3751,188 @@ func getUsernameAndPassword(username string, password string, product int) (stri
 	return username, password
 }
 
-func login(baseURI string, user string, pass string, p params) (string, int, error) {
-	var body []byte
+// readSecretOptions reads the secrets given by --password-file,
+// --password-stdin, --key-file-for-db and --keyfilepass-file as if they were
+// given by -p, --key and --keyfilepass. "-" reads the standard input.
+func readSecretOptions(cFlags *commandOptions) error {
+	passwordOption := "--password-file"
+	if cFlags.passwordStdin {
+		if len(cFlags.passwordFile) > 0 {
+			return errors.New("--password-file and --password-stdin cannot be used together")
+		}
+		passwordOption = "--password-stdin"
+		cFlags.passwordFile = "-"
+	}
+
+	secrets := []struct {
+		option string
+		file   string
+		flag   string
+		value  *string
+	}{
+		{passwordOption, cFlags.passwordFile, "-p", &cFlags.password},
+		{"--key-file-for-db", cFlags.keyFileForDB, "--key", &cFlags.key},
+		{"--keyfilepass-file", cFlags.keyFilePassFile, "--keyfilepass", &cFlags.keyFilePass},
+	}
+
+	stdinOption := ""
+	for _, secret := range secrets {
+		if len(secret.file) > 0 && len(*secret.value) > 0 {
+			return errors.New(secret.flag + " and " + secret.option + " cannot be used together")
+		}
+		if secret.file == "-" {
+			if len(stdinOption) > 0 {
+				return errors.New(stdinOption + " and " + secret.option + " cannot both read the standard input")
+			}
+			stdinOption = secret.option
+		}
+	}
+
+	for _, secret := range secrets {
+		if len(secret.file) == 0 {
+			continue
+		}
+		value, err := readSecret(secret.file)
+		if err != nil {
+			return errors.New(secret.option + ": " + err.Error())
+		}
+		if len(value) == 0 {
+			return errors.New(secret.option + ": the secret is empty")
+		}
+		*secret.value = value
+	}
+
+	return nil
+}
+
+// readSecret returns the first line of the file, or of the standard input
+// when name is "-".
+func readSecret(name string) (string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.SplitN(string(data), "\n", 2)[0]
+
+	return strings.TrimSuffix(secret, "\r"), nil
+}
+
+func loadProfile(name string) (*profile.Profile, error) {
+	path, err := profile.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := profile.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Get(name)
+}
+
+func runPasswordCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	// use the first line of the output
+	password := strings.SplitN(string(out), "\n", 2)[0]
+
+	return strings.TrimSuffix(password, "\r"), nil
+}
+
+func login(client *fmsadmin.Client, user string, pass string, p params) (string, int, error) {
 	var err error
 	token := ""
 	exitStatus := 0
 
-	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(baseURI)) {
+	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(client.BaseURL)) {
 		// for Claris FileMaker Cloud
 		exitStatus = 21
 		err = fmt.Errorf("%s", "Not Supported")
 	} else {
 		// for Claris FileMaker Server
-		username := user
-		password := pass
-		if p.identityFile == "" {
-			username, password = getUsernameAndPassword(user, pass, 1)
+		token = getCachedToken(client, user, pass, p)
+		if token != "" {
+			// reuse the session created by the LOGIN command
+			return token, exitStatus, err
 		}
 
-		u, _ := url.Parse(baseURI)
-		u.Path = path.Join(getAPIBasePath(), "user", "auth")
-
-		output := output{}
-		if p.identityFile == "" {
-			body, _, err = callURL("POST", u.String(), "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil)
+		var helper *credential.Helper
+		helperCredential := credential.Credential{}
+		identity := ""
+		if p.identityFile == "" && p.signerCommand == "" {
+			username, password := user, pass
+			if len(p.credentialHelper) > 0 && len(password) == 0 && len(p.passwordCommand) == 0 {
+				// ask the credential helper before prompting
+				helper = credential.NewHelper(p.credentialHelper)
+				helperCredential, err = helper.Get(credential.ForURL(client.BaseURL, username))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "fmcsadmin: credential helper failed: "+err.Error())
+				}
+				if len(username) == 0 {
+					username = helperCredential.Username
+				}
+				if username == helperCredential.Username {
+					password = helperCredential.Password
+				}
+			}
+			username, password = getUsernameAndPassword(username, password, 1, p.passwordCommand)
+			identity = getSessionIdentity(username, p)
+			err = client.Login(username, password)
+			if helper != nil {
+				cred := credential.ForURL(client.BaseURL, username)
+				cred.Password = password
+				var apiErr *fmsadmin.Error
+				if err == nil && password != helperCredential.Password {
+					// save the credential entered by the user
+					_ = helper.Store(cred)
+				} else if errors.As(err, &apiErr) && len(password) > 0 && password == helperCredential.Password {
+					_ = helper.Erase(cred)
+					// prompt for the password when retrying
+					p.credentialHelper = ""
+				}
+			}
 		} else {
 			var jwtToken string
-			jwtToken, exitStatus, err = getJWTToken(p.identityFile)
+			jwtToken, exitStatus, err = getJWTToken(p)
 			if err != nil || exitStatus > 0 {
 				return token, exitStatus, err
 			}
-			body, _, _ = callURL("POST", u.String(), "PKI "+jwtToken, nil)
-		}
-
-		/* for debugging */
-		//fmt.Println(bytes.NewBuffer([]byte(body)))
-
-		if err != nil {
-			exitStatus = 10502
-			return token, exitStatus, err
+			identity = getSessionIdentity(user, p)
+			err = client.LoginPKI(jwtToken)
 		}
 
-		err = json.Unmarshal(body, &output)
-		if err != nil {
-			return token, exitStatus, err
-		}
-
-		code := ""
-		for i := 0; i < len(output.Messages); i++ {
-			if reflect.ValueOf(output.Messages[i].Code).IsValid() {
-				code = output.Messages[i].Code
-				break
+		var apiErr *fmsadmin.Error
+		if err == nil {
+			token = client.Token
+			if p.identity != nil {
+				*p.identity = identity
 			}
-		}
-		if code == "0" {
-			token = output.Response.Token
-		} else {
+		} else if errors.As(err, &apiErr) {
+			err = nil
 			if p.retry > 0 {
 				fmt.Println("fmcsadmin: Permission denied, please try again.")
-				token, exitStatus, err = login(baseURI, user, pass, params{retry: p.retry - 1, identityFile: p.identityFile})
+				p.retry--
+				token, exitStatus, err = login(client, user, pass, p)
 				if err != nil {
 					exitStatus = 10502
 					return token, exitStatus, err
@@ -3122,70 +3941,231 @@ func login(baseURI string, user string, pass string, p params) (string, int, err
 				fmt.Println("fmcsadmin: Permission denied.")
 				exitStatus = 9
 			}
+		} else {
+			exitStatus = 10502
 		}
 	}
 
 	return token, exitStatus, err
 }
 
-func getJWTToken(filePath string) (string, int, error) {
+func getJWTToken(p params) (string, int, error) {
 	// for public key infrastructure (PKI) authentication
-	var err error
-	var pkey *rsa.PrivateKey
-	passphrase := ""
+	var signer pki.Signer
+
+	// Name of public key on FileMaker Server Admin Console
+	keyName := p.keyName
+
+	if len(p.signerCommand) > 0 {
+		// e.g. a wrapper of a PKCS #11 token or an HSM
+		signer = pki.NewCommandSigner(p.signerCommand)
+	} else if strings.HasPrefix(p.identityFile, pki.AgentPrefix) {
+		agentSigner := pki.NewAgentSigner(p.identityFile)
+		if keyName == "" {
+			comment, err := agentSigner.Comment()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "fmcsadmin: "+err.Error())
+				return "", 9, err
+			}
+			keyName = getKeyName(comment)
+		}
+		signer = agentSigner
+	} else {
+		pkey, exitStatus, err := readRSAPrivateKey(p.identityFile)
+		if err != nil || exitStatus > 0 {
+			return "", exitStatus, err
+		}
+		if keyName == "" {
+			keyName = getKeyName(p.identityFile)
+		}
+		signer = &pki.KeySigner{Key: pkey}
+	}
 
-	keyData, keyFormat, exitStatus := detectPrivateKeyFormat(filePath, "")
-	if exitStatus != 0 && exitStatus != 212 {
-		return "", exitStatus, nil
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": keyName,
+		"aud": "fmsadminapi",
+		"exp": time.Now().Add(p.tokenLifetime).Unix(),
+	})
+	signingInput, err := jwtToken.SigningString()
+	if err != nil {
+		return "", 3, err
+	}
+	signature, err := signer.Sign([]byte(signingInput))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fmcsadmin: "+err.Error())
+		return "", 9, err
 	}
 
+	return signingInput + "." + jwtToken.EncodeSegment(signature), 0, nil
+}
+
+// readRSAPrivateKey reads the RSA private key for PKI authentication and
+// prompts for the passphrase when the key is encrypted.
+func readRSAPrivateKey(filePath string) (*rsa.PrivateKey, int, error) {
+	passphrase := ""
+
+	keyData, _, exitStatus := detectPrivateKeyFormat(filePath, "")
 	if exitStatus == 212 {
 		fmt.Print("Enter passphrase: ")
 		bytePassphrase, _ := term.ReadPassword(int(syscall.Stdin))
 		passphrase = string(bytePassphrase)
 		fmt.Printf("\n")
 		keyData, _, exitStatus = detectPrivateKeyFormat(filePath, passphrase)
-	} else if keyFormat == "PRIVATE KEY" || keyFormat == "EC PRIVATE KEY" || keyFormat == "EC PARAMETERS" {
-		exitStatus = 21
-		return "", exitStatus, nil
+		if exitStatus == 212 {
+			exitStatus = 20408
+			return nil, exitStatus, pki.ErrIncorrectPassphrase
+		}
+	}
+	if exitStatus != 0 {
+		return nil, exitStatus, nil
 	}
 
-	// Name of public key on FileMaker Server Admin Console
-	keyName := strings.Replace(filepath.Base(filePath), filepath.Ext(filePath), "", 1)
+	// PKCS #1 or PKCS #8 RSA private key
+	key, _, err := pki.ParsePrivateKey(keyData, []byte(passphrase))
+	if err != nil {
+		exitStatus = 20408
+		return nil, exitStatus, err
+	}
+	pkey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		// RS256 requires an RSA key
+		exitStatus = 21
+		return nil, exitStatus, nil
+	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
-		"iss": strings.Replace(keyName, "_", " ", -1),
-		"aud": "fmsadminapi",
-		"exp": time.Now().Add(time.Minute * 15).Unix(),
-	})
+	return pkey, exitStatus, nil
+}
 
-	if passphrase == "" {
-		pkey, err = jwt.ParseRSAPrivateKeyFromPEM([]byte(keyData))
-		if err != nil {
-			exitStatus = 20408
-			return "", exitStatus, err
-		}
-	} else {
-		pkey, err = jwt.ParseRSAPrivateKeyFromPEMWithPassword([]byte(keyData), passphrase)
-		if err != nil {
-			exitStatus = 20408
-			return "", exitStatus, err
+// generateKeyPair writes an RSA private key for PKI authentication and its
+// public key, naming the files after the name of the public key on Admin
+// Console.
+func generateKeyPair(c *cli, name string, passphrase string) int {
+	dir, base := filepath.Split(name)
+	base = strings.Replace(strings.TrimSuffix(base, ".pem"), " ", "_", -1)
+	if base == "" {
+		fmt.Fprintln(c.outStream, "Key name is not specified.")
+		return 10001
+	}
+	keyFile := filepath.Join(dir, base+".pem")
+	publicKeyFile := filepath.Join(dir, base+".pub")
+	for _, f := range []string{keyFile, publicKeyFile} {
+		if _, err := os.Stat(f); err == nil {
+			fmt.Fprintln(c.outStream, "File "+f+" already exists.")
+			return 20406
 		}
 	}
-	tokenString, _ := jwtToken.SignedString(pkey)
 
-	return tokenString, exitStatus, err
-}
+	key, err := pki.GenerateKey(pki.DefaultKeySize)
+	if err != nil {
+		fmt.Fprintln(c.outStream, err.Error())
+		return -1
+	}
+	keyData, err := pki.MarshalPrivateKey(key, []byte(passphrase))
+	if err != nil {
+		fmt.Fprintln(c.outStream, err.Error())
+		return -1
+	}
+	publicKeyData, err := pki.MarshalPublicKey(&key.PublicKey)
+	if err != nil {
+		fmt.Fprintln(c.outStream, err.Error())
+		return -1
+	}
 
-func detectPrivateKeyFormat(filePath string, keyFilePass string) ([]byte, string, int) {
-	keyType := ""
-	exitStatus := 0
+	if err := writeNewFile(keyFile, keyData, 0600); err != nil {
+		fmt.Fprintln(c.outStream, err.Error())
+		return 20402
+	}
+	if err := writeNewFile(publicKeyFile, publicKeyData, 0644); err != nil {
+		fmt.Fprintln(c.outStream, err.Error())
+		return 20402
+	}
+
+	fmt.Fprintln(c.outStream, "Private key: "+keyFile)
+	fmt.Fprintln(c.outStream, "Public key: "+publicKeyFile)
+	fmt.Fprintln(c.outStream, "Register the public key in Admin Console with the name \""+getKeyName(keyFile)+"\":")
+	fmt.Fprint(c.outStream, string(publicKeyData))
+
+	return 0
+}
 
-	_, err := os.Stat(filePath)
+// writeNewFile writes data to a file that does not exist yet.
+func writeNewFile(name string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
 	if err != nil {
-		exitStatus = 20405
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// testPKIAuthentication logs in with the private key, bypassing the session
+// kept by the LOGIN command, to check that the public key is registered on
+// Admin Console.
+func testPKIAuthentication(c *cli, client *fmsadmin.Client, p params) int {
+	if p.identityFile == "" && p.signerCommand == "" {
+		fmt.Fprintln(c.outStream, "Private key is not specified.")
+		return 10001
+	}
+
+	jwtToken, exitStatus, err := getJWTToken(p)
+	if err != nil || exitStatus > 0 {
+		return exitStatus
+	}
+	claims := jwt.MapClaims{}
+	_, _, _ = jwt.NewParser().ParseUnverified(jwtToken, claims)
+	keyName, _ := claims["iss"].(string)
+
+	var apiErr *fmsadmin.Error
+	err = client.LoginPKI(jwtToken)
+	if err == nil {
+		fmt.Fprintln(c.outStream, "PKI Authentication succeeded with the key \""+keyName+"\".")
+		_ = client.Logout()
+	} else if errors.As(err, &apiErr) {
+		fmt.Fprintln(c.outStream, "PKI Authentication failed. Make sure that the public key is registered in Admin Console with the name \""+keyName+"\".")
+		exitStatus = 9
+	} else {
+		fmt.Fprintln(c.outStream, err.Error())
+		exitStatus = 10502
+	}
+
+	return exitStatus
+}
+
+// getKeyName returns the name of the public key on Admin Console derived from
+// the basename of the private key file ("Admin_Key.pem" for "Admin Key").
+func getKeyName(filePath string) string {
+	keyName := strings.Replace(filepath.Base(filePath), filepath.Ext(filePath), "", 1)
+
+	return strings.Replace(keyName, "_", " ", -1)
+}
+
+// parseDuration parses a positive duration such as the lifetime of the JSON
+// Web Token for PKI authentication as a duration (e.g. "5m") or seconds.
+func parseDuration(s string) (time.Duration, error) {
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		seconds, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
 	}
 
+	return duration, nil
+}
+
+func detectPrivateKeyFormat(filePath string, keyFilePass string) ([]byte, string, int) {
+	keyType := ""
+	exitStatus := 0
+
 	keyData, err := os.ReadFile(filePath)
 	if err != nil {
 		if os.IsPermission(err) {
@@ -3193,90 +4173,123 @@ func detectPrivateKeyFormat(filePath string, keyFilePass string) ([]byte, string
 		} else {
 			exitStatus = 20405
 		}
-	} else {
-		block, _ := pem.Decode(keyData)
-		if block == nil {
-			exitStatus = 20408
-		} else {
-			buf := block.Bytes
-			if x509.IsEncryptedPEMBlock(block) {
-				buf, err = x509.DecryptPEMBlock(block, []byte(keyFilePass))
-				if err != nil {
-					if err == x509.IncorrectPasswordError {
-						exitStatus = 212
-					}
-				}
-			}
+		return keyData, keyType, exitStatus
+	}
 
-			if exitStatus == 0 {
-				switch block.Type {
-				case "RSA PRIVATE KEY":
-					_, err = x509.ParsePKCS1PrivateKey(buf)
-					if err != nil {
-						exitStatus = 20408
-					}
-				case "PRIVATE KEY":
-					_, err := x509.ParsePKCS8PrivateKey(buf)
-					if err != nil {
-						exitStatus = 20408
-					}
-				case "EC PRIVATE KEY":
-					_, err := x509.ParseECPrivateKey(buf)
-					if err != nil {
-						exitStatus = 20408
-					}
-				default:
-					exitStatus = 20408
-				}
-				keyType = block.Type
-			}
-		}
+	_, keyType, err = pki.ParsePrivateKey(keyData, []byte(keyFilePass))
+	if errors.Is(err, pki.ErrPassphraseRequired) || errors.Is(err, pki.ErrIncorrectPassphrase) {
+		exitStatus = 212
+	} else if err != nil {
+		exitStatus = 20408
 	}
 
 	return keyData, keyType, exitStatus
 }
 
-func logout(baseURI string, token string) {
-	u, _ := url.Parse(baseURI)
-	u.Path = path.Join(getAPIBasePath(), "user", "auth", token)
-	sendRequest("DELETE", u.String(), token, params{})
-}
+func logout(client *fmsadmin.Client) {
+	cache := getSessionCache()
+	cachedToken, _, _ := cache.Get(client.BaseURL)
+	if cachedToken != "" && cachedToken == client.Token {
+		// keep the session created by the LOGIN command
+		_ = cache.Touch(client.BaseURL)
+		return
+	}
 
-func getResultCode(v interface{}) int {
-	var resultCode string
+	_ = client.Logout()
+}
 
-	err := scan.ScanTree(v, "/messages[0]/code", &resultCode)
+func getSessionCache() *session.Cache {
+	path, err := session.DefaultPath()
 	if err != nil {
-		return -1
+		path = ""
 	}
-	code, _ := strconv.Atoi(resultCode)
 
-	return code
+	return session.NewCache(path)
 }
 
-func listClients(urlString string, token string, id int) int {
-	usingCloud := false
-	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(urlString)) {
-		usingCloud = true
+// getSessionIdentity returns the identity which the access token is issued
+// to (the username, the private key or the signer command), or an empty
+// string when the identity is not specified.
+func getSessionIdentity(user string, p params) string {
+	if len(p.signerCommand) > 0 {
+		return strings.TrimSpace("signer:" + p.signerCommand + " " + p.keyName)
+	} else if len(p.identityFile) > 0 {
+		return strings.TrimSpace("pki:" + p.identityFile + " " + p.keyName)
+	} else if len(user) > 0 {
+		return "user:" + user
+	}
+
+	return ""
+}
+
+func getCachedToken(client *fmsadmin.Client, user string, pass string, p params) string {
+	cache := getSessionCache()
+	cachedToken, identity, err := cache.Get(client.BaseURL)
+	if err != nil || cachedToken == "" {
+		return ""
+	}
+
+	// don't run the command as another account than the one specified
+	requested := getSessionIdentity(user, p)
+	if requested != "" && requested != identity {
+		return ""
+	} else if requested == "" && (len(pass) > 0 || len(p.passwordCommand) > 0) {
+		return ""
 	}
 
-	body, _, err := callURL("GET", urlString, token, nil)
+	// check that the server still accepts the token
+	client.Token = cachedToken
+	_, err = client.ServerStatus()
 	if err != nil {
-		fmt.Println(err.Error())
-		return -1
+		client.Token = ""
+		if isInvalidTokenError(err) {
+			_ = cache.Delete(client.BaseURL)
+		}
+		return ""
+	}
+
+	return cachedToken
+}
+
+func isInvalidTokenError(err error) bool {
+	var apiErr *fmsadmin.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case 1702, 25006:
+			return true
+		}
+		return apiErr.StatusCode == 401
+	}
+
+	return false
+}
+
+func listClients(c *cli, client *fmsadmin.Client, id int) int {
+	usingCloud := false
+	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(client.BaseURL + "/")) {
+		usingCloud = true
 	}
 
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
+	clients, err := client.ListClients()
 	if err != nil {
-		fmt.Println(err.Error())
+		exitStatus := getExitStatus(err)
+		if exitStatus == 1701 {
+			// when fmserverd is stopping
+			return 10502
+		} else if exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
+		return exitStatus
 	}
 
-	result := getResultCode(v)
-	if result == 1701 {
-		// when fmserverd is stopping
-		return 10502
+	if c.output == "json" || c.format != "" {
+		infoList := []clientInfo{}
+		for _, v := range clients {
+			if v.Status == "NORMAL" && (id < 1 || id == v.ID) {
+				infoList = append(infoList, newClientInfo(v))
+			}
+		}
+		return outputItems(c, "clients", infoList)
 	}
 
 	mode := "NORMAL"
@@ -3284,91 +4297,44 @@ func listClients(urlString string, token string, id int) int {
 		mode = "DETAIL"
 	}
 
-	var count int
-	var c []string
-	var s string
-	var s1 string
-	var userName string
-	var computerName string
-	var extPriv string
-	var ipAddress string
-	var macAddress string
-	var connectTime string
-	var connectDuration string
-	var appVersion string
-	var appLanguage string
-	//var teamLicensed string
 	var fileName string
-	var accountName string
-	var privsetName string
-	//var b bool
 	var data [][]string
-	var sID int
-
-	_ = scan.ScanTree(v, "/response/clients", &c)
-	count = len(c)
 
 	if mode == "NORMAL" {
-		if count > 0 {
-			for i := 0; i < count; i++ {
-				_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/status", &s)
-				if s == "NORMAL" {
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/id", &s1)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/userName", &userName)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/computerName", &computerName)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/extpriv", &extPriv)
-					data = append(data, []string{s1, userName, computerName, extPriv})
+		if len(clients) > 0 {
+			for _, v := range clients {
+				if v.Status == "NORMAL" {
+					data = append(data, []string{strconv.Itoa(v.ID), v.UserName, v.ComputerName, v.ExtPriv})
 				}
 			}
 
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"Client ID", "User Name", "Computer Name", "Ext Privilege"})
-			table.SetAutoWrapText(false)
-			table.SetAutoFormatHeaders(false)
-			for _, v := range data {
-				table.Append(v)
-			}
-			table.Render()
+			outputTable(c, []string{"Client ID", "User Name", "Computer Name", "Ext Privilege"}, data)
 		}
 	} else {
-		if count > 0 {
-			for i := 0; i < count; i++ {
-				_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/status", &s)
-				if s == "NORMAL" {
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/id", &s1)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/userName", &userName)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/computerName", &computerName)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/extpriv", &extPriv)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/ipaddress", &ipAddress)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/macaddress", &macAddress)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/connectTime", &connectTime)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/connectDuration", &connectDuration)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/appVersion", &appVersion)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/appLanguage", &appLanguage)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/guestFiles[0]/filename", &fileName)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/guestFiles[0]/accountName", &accountName)
-					_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(i)+"]/guestFiles[0]/privsetName", &privsetName)
-
-					connectTime = getDateTimeStringOfCurrentTimeZone(connectTime, "2006/01/02 15:04:05", usingCloud)
-					if regexp.MustCompile(`(.*)\.fmp12`).Match([]byte(fileName)) {
-						rep := regexp.MustCompile(`(.*)\.fmp12`)
-						fileName = rep.ReplaceAllString(fileName, "$1")
+		if len(clients) > 0 {
+			for _, v := range clients {
+				if v.Status == "NORMAL" && (id == v.ID || id == 0) {
+					connectTime := getDateTimeStringOfCurrentTimeZone(v.ConnectTime, "2006/01/02 15:04:05", usingCloud)
+					guestFiles := v.GuestFiles
+					if len(guestFiles) == 0 {
+						guestFiles = []fmsadmin.GuestFile{{}}
 					}
 
-					data = append(data, []string{s1, userName, computerName, extPriv, ipAddress, macAddress, connectTime, connectDuration, appVersion, appLanguage, fileName, accountName, privsetName})
+					// one row for each guest file of the client
+					for _, guestFile := range guestFiles {
+						fileName = guestFile.Filename
+						if regexp.MustCompile(`(.*)\.fmp12`).Match([]byte(fileName)) {
+							rep := regexp.MustCompile(`(.*)\.fmp12`)
+							fileName = rep.ReplaceAllString(fileName, "$1")
+						}
+
+						data = append(data, []string{strconv.Itoa(v.ID), v.UserName, v.ComputerName, v.ExtPriv, v.IPAddress, v.MACAddress, connectTime, v.ConnectDuration, v.AppVersion, v.AppLanguage, fileName, guestFile.AccountName, guestFile.PrivsetName})
+					}
 				}
 			}
 
-			sID, _ = strconv.Atoi(s1)
-			if id == sID || id == 0 {
-				table := tablewriter.NewWriter(os.Stdout)
-				table.SetHeader([]string{"Client ID", "User Name", "Computer Name", "Ext Privilege", "IP Address", "MAC Address", "Connect Time", "Duration", "App Version", "App Language", "File Name", "Account Name", "Privilege Set"})
-				table.SetAutoWrapText(false)
-				table.SetAutoFormatHeaders(false)
-				for _, v := range data {
-					table.Append(v)
-				}
-				table.Render()
+			if len(data) > 0 {
+				outputTable(c, []string{"Client ID", "User Name", "Computer Name", "Ext Privilege", "IP Address", "MAC Address", "Connect Time", "Duration", "App Version", "App Language", "File Name", "Account Name", "Privilege Set"}, data)
 			}
 		}
 	}
@@ -3376,27 +4342,17 @@ func listClients(urlString string, token string, id int) int {
 	return 0
 }
 
-func listFiles(c *cli, url string, token string, idList []int) int {
-	body, _, err := callURL("GET", url, token, nil)
-	if err != nil {
-		fmt.Println(err.Error())
-		return -1
-	}
-
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
-
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
+func listFiles(c *cli, client *fmsadmin.Client, idList []int) int {
+	databases, err := client.ListDatabases()
 	if err != nil {
-		fmt.Println(err.Error())
-	}
-
-	result := getResultCode(v)
-	if result == 1701 {
-		// when fmserverd is stopping
-		return 10502
+		exitStatus := getExitStatus(err)
+		if exitStatus == 1701 {
+			// when fmserverd is stopping
+			return 10502
+		} else if exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
+		return exitStatus
 	}
 
 	mode := "NORMAL"
@@ -3404,84 +4360,69 @@ func listFiles(c *cli, url string, token string, idList []int) int {
 		mode = "DETAIL"
 	}
 
-	var totalDbCount int
-	var count []string
-	var s string
-	var s1 string
-	var fileName string
-	var status string
+	if c.output == "json" || c.format != "" {
+		infoList := []fileInfo{}
+		for _, v := range databases {
+			if mode == "NORMAL" {
+				if v.Status == "NORMAL" {
+					infoList = append(infoList, newFileInfo(v))
+				}
+				continue
+			}
+			for j := 0; j < len(idList); j++ {
+				if v.ID == idList[j] || idList[j] == 0 {
+					infoList = append(infoList, newFileInfo(v))
+					break
+				}
+			}
+		}
+		return outputItems(c, "files", infoList)
+	}
+
 	var extPriv string
 	var isEncrypted string
-	var num1 int
-	var num2 int
-	var b bool
 	var data [][]string
 
-	_ = scan.ScanTree(v, "/response/totalDBCount", &totalDbCount)
-
 	if mode == "NORMAL" {
-		for i := 0; i < totalDbCount; i++ {
-			_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/status", &s)
-			if s == "NORMAL" {
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/folder", &s)
-				fmt.Fprint(c.outStream, s)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/filename", &s)
-				fmt.Fprintln(c.outStream, s)
+		for _, v := range databases {
+			if v.Status == "NORMAL" {
+				fmt.Fprint(c.outStream, v.Folder)
+				fmt.Fprintln(c.outStream, v.Filename)
 			}
 		}
 	} else {
-		for i := 0; i < totalDbCount; i++ {
-			_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/id", &s1)
+		for _, v := range databases {
 			for j := 0; j < len(idList); j++ {
-				if s1 == strconv.Itoa(idList[j]) || idList[j] == 0 {
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/filename", &fileName)
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/clients", &num1)
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/size", &num2)
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/status", &status)
-
-					if status == "CLOSED" {
+				if v.ID == idList[j] || idList[j] == 0 {
+					if v.Status == "CLOSED" {
 						extPriv = "-"
 					} else {
-						extPriv = ""
-						_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/enabledExtPrivileges", &count)
-						for j := 0; j < len(count); j++ {
-							_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/enabledExtPrivileges["+strconv.Itoa(j)+"]", &s)
-							if extPriv == "" {
-								extPriv = s
-							} else {
-								extPriv = extPriv + " " + s
-							}
-						}
+						extPriv = strings.Join(v.EnabledExtPrivileges, " ")
 					}
 
-					isEncrypted = ""
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/isEncrypted", &b)
-					if b {
+					isEncrypted = "No"
+					if v.IsEncrypted {
 						isEncrypted = "Yes"
-					} else {
-						isEncrypted = "No"
 					}
 
-					data = append(data, []string{s1, fileName, strconv.Itoa(num1), strconv.Itoa(num2), status[:1] + strings.ToLower(status[1:]), extPriv, isEncrypted})
+					status := v.Status
+					if len(status) > 0 {
+						status = status[:1] + strings.ToLower(status[1:])
+					}
+
+					data = append(data, []string{strconv.Itoa(v.ID), v.Filename, strconv.Itoa(v.Clients), strconv.FormatInt(v.Size, 10), status, extPriv, isEncrypted})
 				}
 			}
 		}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"ID", "File", "Clients", "Size", "Status", "Enabled Extended Privileges", "Encrypted"})
-		table.SetAutoWrapText(false)
-		table.SetAutoFormatHeaders(false)
-		for _, v := range data {
-			table.Append(v)
-		}
-		table.Render()
+		outputTable(c, []string{"ID", "File", "Clients", "Size", "Status", "Enabled Extended Privileges", "Encrypted"}, data)
 	}
 
 	return 0
 }
 
-func getServerVersion(url string, token string) float64 {
-	versionString, err := getServerVersionString(url, token)
+func getServerVersion(client *fmsadmin.Client) float64 {
+	versionString, err := getServerVersionString(client)
 	if err != nil {
 		return 0.0
 	}
@@ -3494,29 +4435,13 @@ func getServerVersion(url string, token string) float64 {
 	return version
 }
 
-func getServerVersionString(urlString string, token string) (string, error) {
-	body, _, err := callURL("GET", urlString, token, nil)
-	if err != nil {
-		return "0.0.0", err
-	}
-
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
-
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
-	if err != nil {
-		return "0.0.0", err
-	}
-
-	var versionString string
-	err = scan.ScanTree(v, "/response/ServerVersion", &versionString)
+func getServerVersionString(client *fmsadmin.Client) (string, error) {
+	metadata, err := client.GetServerMetadata()
 	if err != nil {
 		return "0.0.0", err
 	}
 
-	return versionString, err
+	return metadata.ServerVersion, nil
 }
 
 func getServerVersionAsFloat(versionString string) (float64, error) {
@@ -3528,197 +4453,101 @@ func getServerVersionAsFloat(versionString string) (float64, error) {
 	return version, err
 }
 
-func listPlugins(url string, token string) int {
-	body, _, err := callURL("GET", url, token, nil)
+func listPlugins(c *cli, client *fmsadmin.Client) int {
+	plugins, err := client.ListPlugins()
 	if err != nil {
-		fmt.Println(err.Error())
-		return -1
-	}
-
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
-
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
-	if err != nil {
-		fmt.Println(err.Error())
+		exitStatus := getExitStatus(err)
+		if exitStatus == 1701 {
+			// when fmserverd is stopping
+			return 10502
+		} else if exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
+		return exitStatus
 	}
 
-	result := getResultCode(v)
-	if result == 1701 {
-		// when fmserverd is stopping
-		return 10502
+	if c.output == "json" || c.format != "" {
+		infoList := []pluginInfo{}
+		for _, v := range plugins {
+			infoList = append(infoList, pluginInfo{ID: v.ID, Name: v.PluginName, Filename: v.Filename, Enabled: v.Enabled})
+		}
+		return outputItems(c, "plugins", infoList)
 	}
 
-	var count int
-	var c []string
-	var s1 string
-	var pluginName string
-	var fileName string
-	var enabled bool
 	var status string
 	var data [][]string
 
-	_ = scan.ScanTree(v, "/response/plugins", &c)
-	count = len(c)
-
-	if count > 0 {
-		for i := 0; i < count; i++ {
-			_ = scan.ScanTree(v, "/response/plugins["+strconv.Itoa(i)+"]/id", &s1)
-			_ = scan.ScanTree(v, "/response/plugins["+strconv.Itoa(i)+"]/pluginName", &pluginName)
-			_ = scan.ScanTree(v, "/response/plugins["+strconv.Itoa(i)+"]/filename", &fileName)
-			_ = scan.ScanTree(v, "/response/plugins["+strconv.Itoa(i)+"]/enabled", &enabled)
+	if len(plugins) > 0 {
+		for _, v := range plugins {
 			status = "Disabled"
-			if enabled {
+			if v.Enabled {
 				status = "Enabled"
 			}
-			data = append(data, []string{s1, pluginName, fileName, status})
+			data = append(data, []string{strconv.Itoa(v.ID), v.PluginName, v.Filename, status})
 		}
 
-		if len(data) > 0 {
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetAutoWrapText(false)
-			table.SetAutoFormatHeaders(false)
-			for _, v := range data {
-				table.SetHeader([]string{"ID", "Name", "File", "Status"})
-				table.Append(v)
-			}
-			table.Render()
-		}
+		outputTable(c, []string{"ID", "Name", "File", "Status"}, data)
 	}
 
 	return 0
 }
 
-func listSchedules(urlString string, token string, id int) int {
+func listSchedules(c *cli, client *fmsadmin.Client, id int) int {
 	usingCloud := false
-	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(urlString)) {
+	if regexp.MustCompile(`https://(.*)\.account\.filemaker-cloud\.com/`).Match([]byte(client.BaseURL + "/")) {
 		usingCloud = true
 	}
 
-	body, _, err := callURL("GET", urlString, token, nil)
+	schedules, err := client.ListSchedules()
 	if err != nil {
-		fmt.Println(err.Error())
-		return -1
+		exitStatus := getExitStatus(err)
+		if exitStatus == 1701 {
+			// when fmserverd is stopping
+			return 10502
+		} else if exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
+		return exitStatus
 	}
 
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
+	if c.output == "json" || c.format != "" {
+		infoList := []scheduleInfo{}
+		for _, v := range schedules {
+			if id == v.ID || id == 0 {
+				infoList = append(infoList, newScheduleInfo(v))
+			}
+		}
+		if len(schedules) > 0 && len(infoList) == 0 {
+			return 10600
+		}
+		return outputItems(c, "schedules", infoList)
+	}
 
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-
-	result := getResultCode(v)
-	if result == 1701 {
-		// when fmserverd is stopping
-		return 10502
-	}
-
-	var count int
-	var c []string
-	var s1 string
-	var sID int
-	var name string
-	var taskType string
-	var backupType string
-	var filemakerScriptType string
-	var messageType string
-	var scriptSequenceType string
-	var systemScriptType string
-	var verifyType string
-	var lastRun string
-	var nextRun string
-	var enabled bool
-	var status string
 	var data [][]string
 
-	_ = scan.ScanTree(v, "/response/schedules", &c)
-	count = len(c)
-
-	if count > 0 {
-		for i := 0; i < count; i++ {
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/id", &s1)
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/name", &name)
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/backupType/resourceType", &backupType)
-			if err != nil {
-				backupType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/filemakerScriptType/resource", &filemakerScriptType)
-			if err != nil {
-				filemakerScriptType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/messageType/resourceType", &messageType)
-			if err != nil {
-				messageType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/scriptSequenceType/resource", &scriptSequenceType)
-			if err != nil {
-				scriptSequenceType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/systemScriptType/osScript", &systemScriptType)
-			if err != nil {
-				systemScriptType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/verifyType/resourceType", &verifyType)
-			if err != nil {
-				verifyType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/lastRun", &lastRun)
-			if err != nil {
-				lastRun = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/nextRun", &nextRun)
-			if err != nil {
-				nextRun = ""
-			}
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/enabled", &enabled)
-			if !enabled {
-				nextRun = "Disabled"
-			}
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/status", &status)
-
-			sID, _ = strconv.Atoi(s1)
-			if id == sID || id == 0 {
+	if len(schedules) > 0 {
+		for _, v := range schedules {
+			if id == v.ID || id == 0 {
+				status := v.Status
 				if status == "IDLE" || status == "RUNNING" {
-					if lastRun == "" || lastRun == "0000-00-00T00:00:00" {
+					if v.LastRun == "" || v.LastRun == "0000-00-00T00:00:00" {
 						status = ""
 					} else {
 						status = "OK"
 					}
 				}
-				if backupType != "" {
-					taskType = "Backup"
-				} else if filemakerScriptType != "" {
-					taskType = "FileMaker Script"
-				} else if messageType != "" {
-					taskType = "Message"
-				} else if scriptSequenceType != "" {
-					taskType = "Script Sequence"
-				} else if systemScriptType != "" {
-					taskType = "System Script"
-				} else if verifyType != "" {
-					taskType = "Verify"
+				nextRun := v.NextRun
+				if !v.Enabled {
+					nextRun = "Disabled"
 				}
-				lastRun = getDateTimeStringOfCurrentTimeZone(lastRun, "2006/01/02 15:04", usingCloud)
+				lastRun := getDateTimeStringOfCurrentTimeZone(v.LastRun, "2006/01/02 15:04", usingCloud)
 				nextRun = getDateTimeStringOfCurrentTimeZone(nextRun, "2006/01/02 15:04", usingCloud)
-				data = append(data, []string{s1, name, taskType, lastRun, nextRun, status})
+				data = append(data, []string{strconv.Itoa(v.ID), v.Name, v.TaskType(), lastRun, nextRun, status})
 			}
 		}
 
 		if len(data) > 0 {
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetAutoWrapText(false)
-			table.SetAutoFormatHeaders(false)
-			for _, v := range data {
-				table.SetHeader([]string{"ID", "Name", "Type", "Last Completed", "Next Run", "Status"})
-				table.Append(v)
-			}
-			table.Render()
+			outputTable(c, []string{"ID", "Name", "Type", "Last Completed", "Next Run", "Status"}, data)
 		} else {
 			return 10600
 		}
@@ -3727,129 +4556,194 @@ func listSchedules(urlString string, token string, id int) int {
 	return 0
 }
 
-func getScheduleName(url string, token string, id int) string {
-	body, _, err := callURL("GET", url, token, nil)
-	if err != nil {
-		//fmt.Println(err.Error())
-		return ""
-	}
-
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
-
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
+func getScheduleName(client *fmsadmin.Client, id int) string {
+	schedule, err := client.GetSchedule(id)
 	if err != nil {
-		//fmt.Println(err.Error())
 		return ""
 	}
 
-	var s1 string
-	var sID int
-	var name string
-
-	_ = scan.ScanTree(v, "/response/schedule/id", &s1)
-	_ = scan.ScanTree(v, "/response/schedule/name", &name)
-	sID, _ = strconv.Atoi(s1)
-	if id == sID || id == 0 {
-		return name
+	if id == schedule.ID || id == 0 {
+		return schedule.Name
 	}
 
 	return ""
 }
 
-func sendMessages(u *url.URL, token string, message string, cmdArgs []string, clientID int) int {
-	var exitStatus int
-
+func sendMessages(c *cli, client *fmsadmin.Client, message string, cmdArgs []string, clientID int, conditions []clientCondition) int {
 	args := []string{""}
 	if len(cmdArgs[1:]) > 0 {
 		args = cmdArgs[1:]
 	}
-	u.Path = path.Join(getAPIBasePath(), "clients")
-	idList := getClients(u.String(), token, args)
-	if len(idList) > 0 {
-		for i := 0; i < len(idList); i++ {
-			if clientID == -1 || clientID == idList[i] {
-				u.Path = path.Join(getAPIBasePath(), "clients", strconv.Itoa(idList[i]), "message")
-				exitStatus = sendMessage(u.String(), token, message)
-			}
-			if clientID > 0 {
-				break
-			}
+	idList := getClients(client, args)
+	if len(idList) == 0 {
+		return 10904
+	}
+
+	return sendMatchingMessages(c, client, message, idList, clientID, conditions)
+}
+
+// sendMatchingMessages sends the message to the connected clients in idList
+// that meet the conditions, and prints the result of each client. When
+// clientID is not -1, only that client is messaged; nil conditions match
+// every client.
+func sendMatchingMessages(c *cli, client *fmsadmin.Client, message string, idList []int, clientID int, conditions []clientCondition) int {
+	clients, err := client.ListClients()
+	if err != nil {
+		exitStatus := getExitStatus(err)
+		if exitStatus == -1 || exitStatus == 3 {
+			fmt.Fprintln(c.outStream, err.Error())
 		}
-	} else {
-		exitStatus = 10904
+		return exitStatus
+	}
+
+	selected := map[int]bool{}
+	for i := 0; i < len(idList); i++ {
+		selected[idList[i]] = true
+	}
+
+	exitStatus := 0
+	var data [][]string
+	for _, v := range clients {
+		if v.Status != "NORMAL" || !selected[v.ID] || (clientID != -1 && v.ID != clientID) || !matchClient(conditions, v) {
+			continue
+		}
+
+		result := getExitStatus(client.SendMessage(v.ID, message))
+		if result != 0 {
+			exitStatus = result
+		}
+		data = append(data, []string{strconv.Itoa(v.ID), v.UserName, v.ComputerName, getDeliveryResult(result)})
 	}
+	if len(data) == 0 {
+		return 10904
+	}
+	outputTable(c, []string{"Client ID", "User Name", "Computer Name", "Result"}, data)
 
 	return exitStatus
 }
 
-func sendMessage(url string, token string, message string) int {
-	d := messageInfo{
-		message,
+// getDeliveryResult returns the result code of sending a message with its
+// description.
+func getDeliveryResult(result int) string {
+	switch result {
+	case 0:
+		return "0"
+	case dryRunStatus:
+		return "Not sent (--dry-run)"
 	}
-	jsonStr, _ := json.Marshal(d)
-	body, _, err := callURL("POST", url, token, bytes.NewBuffer([]byte(jsonStr)))
-	if err != nil {
-		fmt.Println(err.Error())
+
+	return strconv.Itoa(result) + " (" + getErrorDescription(result) + ")"
+}
+
+func getDatabases(client *fmsadmin.Client, arg []string, status string, fullPath bool) ([]int, []string, []string) {
+	return selectDatabases(client, arg, status, fullPath, databaseSelector{})
+}
+
+// databaseSelector narrows down the databases selected by the arguments of a
+// command with the --match and --exclude options.
+type databaseSelector struct {
+	match   *regexp.Regexp
+	exclude string
+}
+
+func (s databaseSelector) isSet() bool {
+	return s.match != nil || len(s.exclude) > 0
+}
+
+// selects reports whether the file name of a database matches the regular
+// expression of --match and does not match the pattern of --exclude. The
+// file name is also tested without the extension.
+func (s databaseSelector) selects(fileName string) bool {
+	baseName := strings.TrimSuffix(fileName, ".fmp12")
+	if s.match != nil && !s.match.MatchString(fileName) && !s.match.MatchString(baseName) {
+		return false
 	}
 
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
+	return len(s.exclude) == 0 || !matchGlobPattern(s.exclude, fileName)
+}
 
-	code := -1
-	output := output{}
-	err = json.Unmarshal(body, &output)
-	if err != nil {
-		fmt.Println(err.Error())
-	} else {
-		for i := 0; i < len(output.Messages); i++ {
-			if reflect.ValueOf(output.Messages[i].Code).IsValid() {
-				code, _ = strconv.Atoi(output.Messages[i].Code)
-				break
-			}
+// hasGlobPattern reports whether any argument is a glob pattern of file
+// names (e.g. "Invoices_*").
+func hasGlobPattern(args []string) bool {
+	for _, arg := range args {
+		if isGlobPattern(arg) {
+			return true
 		}
 	}
 
-	return code
+	return false
+}
+
+func isGlobPattern(arg string) bool {
+	return !strings.Contains(arg, string(os.PathSeparator)) && strings.ContainsAny(arg, "*?[")
+}
+
+// matchGlobPattern reports whether the file name matches the glob pattern
+// with or without the extension.
+func matchGlobPattern(pattern string, fileName string) bool {
+	if matched, _ := path.Match(pattern, fileName); matched {
+		return true
+	}
+	matched, _ := path.Match(pattern, strings.TrimSuffix(fileName, ".fmp12"))
+
+	return matched
+}
+
+// confirmDatabases lists the databases selected by patterns and asks whether
+// to perform the action unless --yes is specified.
+func confirmDatabases(c *cli, nameList []string, action string, yesFlag bool) bool {
+	fmt.Fprintln(c.outStream, "Matching database(s):")
+	for i := 0; i < len(nameList); i++ {
+		fmt.Fprintln(c.outStream, "    "+nameList[i])
+	}
+	if yesFlag {
+		return true
+	}
+
+	r := bufio.NewReader(os.Stdin)
+	fmt.Fprint(c.outStream, "fmcsadmin: really "+action+" database(s)? (y, n) ")
+	input, _ := r.ReadString('\n')
+
+	return strings.ToLower(strings.TrimSpace(input)) == "y"
 }
 
-func getDatabases(url string, token string, arg []string, status string, fullPath bool) ([]int, []string, []string) {
+func selectDatabases(client *fmsadmin.Client, arg []string, status string, fullPath bool, selector databaseSelector) ([]int, []string, []string) {
+	idList, nameList, hintList, _ := selectDatabasesWithPatterns(client, arg, status, fullPath, selector)
+	return idList, nameList, hintList
+}
+
+// selectDatabasesWithPatterns selects the databases like selectDatabases and
+// also reports whether any argument is used as a glob pattern. An argument
+// is a glob pattern only when it is not the name of any database (e.g.
+// "Sales [2024].fmp12").
+func selectDatabasesWithPatterns(client *fmsadmin.Client, arg []string, status string, fullPath bool, selector databaseSelector) ([]int, []string, []string, bool) {
 	var fileName string
 	var folderName string
 	var idList []int
 	var nameList []string
 	var hintList []string
-	var id int
 
-	body, _, err := callURL("GET", url, token, nil)
+	databases, err := client.ListDatabases()
 	if err != nil {
-		fmt.Println(err.Error())
-		return idList, nameList, hintList
+		if exitStatus := getExitStatus(err); exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
+		return idList, nameList, hintList, false
 	}
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		fmt.Println(err.Error())
+	patterns := make([]bool, len(arg))
+	for j := 0; j < len(arg); j++ {
+		patterns[j] = isGlobPattern(arg[j])
+		for _, v := range databases {
+			if patterns[j] && comparePath(arg[j], v.Filename) {
+				// the exact name of the database
+				patterns[j] = false
+			}
+		}
 	}
 
-	// for debugging
-	/*
-		fmt.Println(url)
-		fmt.Println(bytes.NewBuffer([]byte(body)))
-	*/
-
-	var totalDbCount int
-	var fileStatus string
-	var s1 string
-	var s2 string
-	var fileID string
-	var decryptHint string
-
-	_ = scan.ScanTree(v, "/response/totalDBCount", &totalDbCount)
-	for i := 0; i < totalDbCount; i++ {
+	patternUsed := false
+	for _, v := range databases {
 		for j := 0; j < len(arg)+1; j++ {
 			if j == len(arg) && j > 0 {
 				break
@@ -3864,57 +4758,33 @@ func getDatabases(url string, token string, arg []string, status string, fullPat
 				}
 			}
 
-			if len(folderName) == 0 {
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/status", &fileStatus)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/filename", &s1)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/folder", &s2)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/decryptHint", &decryptHint)
-				if regexp.MustCompile(`^[0-9]+$`).Match([]byte(arg[j])) {
+			matched := false
+			if status == v.Status || status == "" {
+				if len(folderName) > 0 {
+					matched = comparePath(v.Folder, folderName) || comparePath(v.Folder+v.Filename, fileName)
+				} else if j < len(patterns) && patterns[j] {
+					matched = matchGlobPattern(fileName, v.Filename)
+					patternUsed = true
+				} else if regexp.MustCompile(`^[0-9]+$`).Match([]byte(fileName)) {
 					// ID
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/id", &fileID)
-					if fileID == arg[j] && (status == fileStatus || status == "") {
-						if fullPath {
-							// for "remove" command
-							nameList = append(nameList, s2+s1)
-						} else {
-							nameList = append(nameList, s1)
-						}
-						id, _ = strconv.Atoi(fileID)
-						idList = append(idList, id)
-						hintList = append(hintList, decryptHint)
-					}
+					matched = strconv.Itoa(v.ID) == fileName
 				} else {
 					// name
-					if (fileName == "" || comparePath(fileName, s1)) && (status == fileStatus || status == "") {
-						if fullPath {
-							// for "remove" command
-							nameList = append(nameList, s2+s1)
-						} else {
-							nameList = append(nameList, s1)
-						}
-						_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/id", &fileID)
-						id, _ = strconv.Atoi(fileID)
-						idList = append(idList, id)
-						hintList = append(hintList, decryptHint)
-					}
+					matched = fileName == "" || comparePath(fileName, v.Filename)
 				}
-			} else {
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/status", &fileStatus)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/folder", &s1)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/filename", &s2)
-				_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/decryptHint", &decryptHint)
-				if (status == fileStatus || status == "") && (comparePath(s1, folderName) || comparePath(s1+s2, fileName)) {
-					if fullPath {
-						// for "remove" command
-						nameList = append(nameList, s1+s2)
-					} else {
-						nameList = append(nameList, s2)
-					}
-					_ = scan.ScanTree(v, "/response/databases["+strconv.Itoa(i)+"]/id", &fileID)
-					id, _ = strconv.Atoi(fileID)
-					idList = append(idList, id)
-					hintList = append(hintList, decryptHint)
+			}
+
+			if matched && selector.selects(v.Filename) {
+				if fullPath {
+					// for "remove" command
+					nameList = append(nameList, v.Folder+v.Filename)
+				} else {
+					nameList = append(nameList, v.Filename)
 				}
+				idList = append(idList, v.ID)
+				hintList = append(hintList, v.DecryptHint)
+				// select each database once even if several arguments match
+				break
 			}
 		}
 	}
@@ -3932,35 +4802,394 @@ func getDatabases(url string, token string, arg []string, status string, fullPat
 		}
 	}
 
-	return idList, nameList, hintList
+	return idList, nameList, hintList, patternUsed
 }
 
-func getClients(url string, token string, arg []string) []int {
+// defaultDrainTimeout is the time limit of the --drain option.
+const defaultDrainTimeout = 15 * time.Minute
+
+// drainInterval is the interval of checking the clients of the databases
+// being drained.
+var drainInterval = 5 * time.Second
+
+// defaultDrainReminderInterval is the interval of the reminder messages to
+// the clients of the databases being drained.
+const defaultDrainReminderInterval = 5 * time.Minute
+
+// drainReminder is sent to the clients when no message is specified.
+const drainReminder = "This database will be closed for maintenance. Please close it as soon as possible."
+
+// drainDatabases closes each database after the clients connected to it
+// disconnect or when the timeout expires. New users are blocked while
+// draining, and the clients are reminded to close the databases at
+// reminderInterval. It returns the result of closing each database.
+func drainDatabases(c *cli, client *fmsadmin.Client, idList []int, nameList []string, message string, forceFlag bool, blockNewUsers bool, timeout time.Duration, reminderInterval time.Duration) []int {
+	results := make([]int, len(idList))
+
+	if blockNewUsers {
+		config, err := client.GetBlockNewUsersConfig()
+		if err == nil && !config.BlockNewUsers {
+			exitStatus := getExitStatus(client.SetBlockNewUsersConfig(fmsadmin.BlockNewUsersConfig{BlockNewUsers: true}))
+			if exitStatus == 0 || exitStatus == dryRunStatus {
+				if exitStatus == 0 {
+					fmt.Fprintln(c.outStream, "New users are blocked.")
+					fmt.Fprintln(c.outStream, "If draining is aborted, allow new users with: fmcsadmin set serverprefs blocknewusersenabled=false")
+				}
+				allowNewUsers := func() {
+					if getExitStatus(client.SetBlockNewUsersConfig(fmsadmin.BlockNewUsersConfig{BlockNewUsers: false})) == 0 {
+						fmt.Fprintln(c.outStream, "New users are allowed.")
+					}
+				}
+
+				// allow new users again when draining is interrupted
+				interrupted := make(chan os.Signal, 1)
+				done := make(chan struct{})
+				signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+				go func() {
+					select {
+					case <-interrupted:
+						allowNewUsers()
+						os.Exit(130)
+					case <-done:
+					}
+				}()
+				defer func() {
+					// allow new users again after draining
+					signal.Stop(interrupted)
+					close(done)
+					allowNewUsers()
+				}()
+			}
+		}
+	} else {
+		fmt.Fprintln(c.outStream, "New users cannot be blocked on this server.")
+	}
+
+	reminder := message
+	if reminder == "" {
+		reminder = drainReminder
+	}
+
+	deadline := time.Now().Add(timeout)
+	if client.DryRun != nil {
+		// clients never disconnect without the reminders
+		deadline = time.Now()
+	}
+
+	pending := make([]int, len(idList))
+	for i := 0; i < len(idList); i++ {
+		pending[i] = i
+	}
+	var lastReminder time.Time
+	for len(pending) > 0 {
+		var waiting []int
+		var clientIDs []int
+		seen := map[int]bool{}
+		for _, i := range pending {
+			connectedClients := getClients(client, []string{nameList[i]})
+			if len(connectedClients) == 0 {
+				results[i] = getExitStatus(client.CloseDatabase(idList[i], message, forceFlag))
+				if results[i] == 0 {
+					fmt.Fprintln(c.outStream, "File Closed: "+nameList[i])
+				}
+				continue
+			}
+			waiting = append(waiting, i)
+			for _, id := range connectedClients {
+				if !seen[id] {
+					seen[id] = true
+					clientIDs = append(clientIDs, id)
+				}
+			}
+		}
+		pending = waiting
+		if len(pending) == 0 {
+			break
+		}
+
+		if time.Since(lastReminder) >= reminderInterval {
+			for _, id := range clientIDs {
+				_ = client.SendMessage(id, reminder)
+			}
+			fmt.Fprintln(c.outStream, "Reminder Sent: "+strconv.Itoa(len(clientIDs))+" client(s)")
+			lastReminder = time.Now()
+		}
+
+		if !time.Now().Before(deadline) {
+			// close the databases disconnecting the remaining clients
+			for _, i := range pending {
+				fmt.Fprintln(c.outStream, "Drain Timed Out: "+nameList[i])
+				results[i] = getExitStatus(client.CloseDatabase(idList[i], message, forceFlag))
+			}
+			break
+		}
+
+		time.Sleep(drainInterval)
+	}
+
+	return results
+}
+
+// defaultWaitTimeout is the time limit of the --wait option.
+const defaultWaitTimeout = 5 * time.Minute
+
+// waitInterval is the interval of polling the status of databases.
+var waitInterval = time.Second
+
+// waitUnchangedPolls is the number of polls after which a database still in
+// the initial status is regarded as failed, when no transition is seen.
+const waitUnchangedPolls = 10
+
+// waitForDatabases polls the status of the databases until each of them
+// changes from the initial status to the final status, and prints the result
+// of each database. results are the result codes of the requests changing
+// the status; databases with a non-zero result are not waited for. A
+// database that stays in the initial status for waitUnchangedPolls polls, or
+// an error reported by the server, ends the wait. It returns non-zero when a
+// database fails or the timeout expires.
+func waitForDatabases(c *cli, client *fmsadmin.Client, idList []int, nameList []string, results []int, initialStatus string, finalStatus string, timeout time.Duration) int {
+	current := make([]string, len(idList))
+	changed := make([]bool, len(idList))
+	unchanged := make([]int, len(idList))
+	done := make([]bool, len(idList))
+	for i := 0; i < len(idList); i++ {
+		current[i] = initialStatus
+		done[i] = results[i] != 0
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		databases, err := client.ListDatabases()
+		var apiErr *fmsadmin.Error
+		if errors.As(err, &apiErr) {
+			// the server reports an error instead of the status
+			for i := 0; i < len(idList); i++ {
+				if !done[i] {
+					current[i] = "error " + strconv.Itoa(apiErr.Code)
+					done[i] = true
+				}
+			}
+		} else if err == nil {
+			statuses := map[int]string{}
+			for _, v := range databases {
+				statuses[v.ID] = v.Status
+			}
+			for i := 0; i < len(idList); i++ {
+				if done[i] {
+					continue
+				}
+				status, ok := statuses[idList[i]]
+				current[i] = status
+				switch {
+				case !ok:
+					// the database has been removed
+					done[i] = true
+				case status == finalStatus:
+					done[i] = true
+				case status == initialStatus:
+					// back to the initial status after a transition, or the
+					// transition has finished or failed between the polls
+					unchanged[i]++
+					done[i] = changed[i] || unchanged[i] >= waitUnchangedPolls
+				case strings.HasSuffix(status, "ING"):
+					// in transition (e.g. OPENING or CLOSING)
+					changed[i] = true
+				default:
+					done[i] = true
+				}
+			}
+		}
+
+		finished := true
+		for i := 0; i < len(idList); i++ {
+			finished = finished && done[i]
+		}
+		if finished || (timeout > 0 && time.Now().After(deadline)) {
+			break
+		}
+		time.Sleep(waitInterval)
+	}
+
+	exitStatus := 0
+	for i := 0; i < len(idList); i++ {
+		result := ""
+		switch {
+		case results[i] != 0:
+			result = "Error: " + strconv.Itoa(results[i]) + " (" + getErrorDescription(results[i]) + ")"
+			if exitStatus == 0 {
+				exitStatus = results[i]
+			}
+		case current[i] == finalStatus:
+			result = finalStatus
+		case !done[i]:
+			result = "Timed out (" + current[i] + ")"
+		case current[i] == "":
+			result = "Failed (not found)"
+		default:
+			result = "Failed (" + current[i] + ")"
+		}
+		if results[i] == 0 && current[i] != finalStatus && exitStatus == 0 {
+			exitStatus = -1
+		}
+		fmt.Fprintln(c.outStream, nameList[i]+": "+result)
+	}
+
+	return exitStatus
+}
+
+// keyMapEntry is the encryption password of a database in the key map file
+// of the --keymap option.
+type keyMapEntry struct {
+	Key     string `json:"key"`
+	SaveKey bool   `json:"saveKey"`
+}
+
+// UnmarshalJSON accepts the encryption password as a string in place of an
+// object.
+func (e *keyMapEntry) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		e.Key = key
+		return nil
+	}
+
+	type entry keyMapEntry
+	return json.Unmarshal(data, (*entry)(e))
+}
+
+// loadKeyMap reads the key map file, a JSON object that maps the file names
+// or the IDs of databases to their encryption passwords.
+func loadKeyMap(name string) (map[string]keyMapEntry, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	keyMap := map[string]keyMapEntry{}
+	err = json.Unmarshal(data, &keyMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyMap, nil
+}
+
+// lookupKeyMap returns the entry of the key map for the database, looking up
+// its ID first and then its file name.
+func lookupKeyMap(keyMap map[string]keyMapEntry, id int, name string) (keyMapEntry, bool) {
+	if entry, ok := keyMap[strconv.Itoa(id)]; ok {
+		return entry, true
+	}
+
+	names := make([]string, 0, len(keyMap))
+	for k := range keyMap {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if comparePath(k, name) {
+			return keyMap[k], true
+		}
+	}
+
+	return keyMapEntry{}, false
+}
+
+// databaseOpened reports whether the database is opened within a few
+// seconds after the request to open it.
+func databaseOpened(client *fmsadmin.Client, id int) bool {
+	// Note: FileMaker Admin API does not validate the encryption key.
+	//       You receive a result code of 0 even if you enter an invalid key.
+	var openedID []int
+	for value := 0; ; {
+		value++
+		openedID, _, _ = getDatabases(client, []string{strconv.Itoa(id)}, "NORMAL", false)
+		if len(openedID) > 0 || value > 3 {
+			break
+		}
+		time.Sleep(1 * time.Second)
+	}
+
+	return len(openedID) > 0
+}
+
+// retryEncryptedDatabases lists the encrypted databases that failed to open
+// with their hints. When interactive is true, it prompts for the encryption
+// password of each of them and opens it again, updating results.
+func retryEncryptedDatabases(c *cli, client *fmsadmin.Client, idList []int, nameList []string, hintList []string, saveKeyList []bool, results []int, interactive bool) {
+	var needKey []int
+	var encrypted map[int]bool
+	for i := 0; i < len(idList); i++ {
+		if results[i] > 0 && encrypted == nil {
+			encrypted = getEncryptedDatabases(client)
+		}
+		if results[i] == 802 || (results[i] > 0 && encrypted[idList[i]]) {
+			needKey = append(needKey, i)
+		}
+	}
+
+	if interactive {
+		var remaining []int
+		for _, i := range needKey {
+			fmt.Fprint(c.outStream, "Encryption password for "+nameList[i]+" (Hint: "+hintList[i]+", empty to skip): ")
+			byteKey, _ := term.ReadPassword(int(syscall.Stdin))
+			fmt.Fprintln(c.outStream)
+			if len(byteKey) == 0 {
+				remaining = append(remaining, i)
+				continue
+			}
+
+			results[i] = getExitStatus(client.OpenDatabase(idList[i], string(byteKey), saveKeyList[i]))
+			if results[i] == 0 && databaseOpened(client, idList[i]) {
+				fmt.Fprintln(c.outStream, "File Opened: "+nameList[i])
+				continue
+			}
+			if results[i] == 0 {
+				results[i] = 802
+			}
+			fmt.Fprintln(c.outStream, "Fail to open encrypted database. (Hint: "+hintList[i]+")")
+			remaining = append(remaining, i)
+		}
+		needKey = remaining
+	}
+
+	if len(needKey) > 0 {
+		fmt.Fprintln(c.outStream, "File(s) still needing the encryption password:")
+		for _, i := range needKey {
+			fmt.Fprintln(c.outStream, "    "+nameList[i]+" (Hint: "+hintList[i]+")")
+		}
+	}
+}
+
+// getEncryptedDatabases returns the IDs of the encrypted databases.
+func getEncryptedDatabases(client *fmsadmin.Client) map[int]bool {
+	encrypted := map[int]bool{}
+
+	databases, err := client.ListDatabases()
+	if err != nil {
+		return encrypted
+	}
+	for _, v := range databases {
+		encrypted[v.ID] = v.IsEncrypted
+	}
+
+	return encrypted
+}
+
+func getClients(client *fmsadmin.Client, arg []string) []int {
 	var fileName string
 	var folderName string
 	var idList []int
-	var id int
+	var databases []fmsadmin.Database
 
-	body, _, err := callURL("GET", url, token, nil)
+	clients, err := client.ListClients()
 	if err != nil {
-		fmt.Println(err.Error())
+		if exitStatus := getExitStatus(err); exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
 		return idList
 	}
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-	var clients []string
-	var guestFiles []string
-	var files []string
-	var guestFileID string
-	var guestFileName string
-	var clientID string
-	var fileID string
-	var directory string
-
 	for i := 0; i < len(arg)+1; i++ {
 		if i == len(arg) && i > 0 {
 			break
@@ -3975,32 +5204,29 @@ func getClients(url string, token string, arg []string) []int {
 			}
 		}
 
-		_ = scan.ScanTree(v, "/response/clients", &clients)
-		for j := 0; j < len(clients); j++ {
-			_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(j)+"]/guestFiles", &guestFiles)
-			for k := 0; k < len(guestFiles); k++ {
-				_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(j)+"]/guestFiles["+strconv.Itoa(k)+"]/filename", &guestFileID)
-				_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(j)+"]/guestFiles["+strconv.Itoa(k)+"]/filename", &guestFileName)
+		if len(folderName) > 0 && databases == nil {
+			// the folder of the guest file is listed in the databases
+			databases, _ = client.ListDatabases()
+		}
+
+		for _, v := range clients {
+			for _, guestFile := range v.GuestFiles {
+				matched := false
 				if len(folderName) == 0 {
-					if fileName == "" || comparePath(fileName, guestFileName) {
-						_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(k)+"]/id", &clientID)
-						id, _ = strconv.Atoi(clientID)
-						idList = append(idList, id)
-					}
+					matched = fileName == "" || comparePath(fileName, guestFile.Filename)
 				} else {
-					_ = scan.ScanTree(v, "/files/files", &files)
-					for l := 0; k < len(files); l++ {
-						_ = scan.ScanTree(v, "/files/files["+strconv.Itoa(k)+"]/id", &fileID)
-						_ = scan.ScanTree(v, "/files/files["+strconv.Itoa(k)+"]/folder", &directory)
-						if fileID == guestFileID {
-							if comparePath(fileName, directory+guestFileName) {
-								_ = scan.ScanTree(v, "/response/clients["+strconv.Itoa(k)+"]/id", &clientID)
-								id, _ = strconv.Atoi(clientID)
-								idList = append(idList, id)
-							}
+					for _, db := range databases {
+						if db.Filename == guestFile.Filename && comparePath(fileName, db.Folder+db.Filename) {
+							matched = true
+							break
 						}
 					}
 				}
+
+				if matched {
+					idList = append(idList, v.ID)
+					break
+				}
 			}
 		}
 	}
@@ -4008,50 +5234,38 @@ func getClients(url string, token string, arg []string) []int {
 	return idList
 }
 
-func getServerGeneralConfigurations(urlString string, token string, printOptions []string) ([]int, int) {
+func getServerGeneralConfigurations(c *cli, client *fmsadmin.Client, printOptions []string) ([]int, int) {
 	var settings []int
-	var resultCode string
-	var result int
-	var cacheSize int
-	var maxFiles int
-	var maxProConnections int
-	var maxPSOS int
-	var startupRestorationEnabled bool
-	var onlyOpenLastOpenedDatabases bool
-
-	versionString, _ := getServerVersionString(strings.Replace(urlString, "/config/general", "/metadata", 1), token)
-	version, _ := getServerVersionAsFloat(versionString)
 
-	body, _, err := callURL("GET", urlString, token, nil)
-	if err != nil {
-		fmt.Println(err.Error())
-		return settings, 10502
-	}
+	versionString, _ := getServerVersionString(client)
+	version, _ := getServerVersionAsFloat(versionString)
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
+	config, err := client.GetGeneralConfig()
 	if err != nil {
-		fmt.Println(err.Error())
+		result := getExitStatus(err)
+		if result == -1 {
+			fmt.Println(err.Error())
+			return settings, 10502
+		}
+		return settings, result
 	}
 
-	/* for debugging */
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
-
-	err = scan.ScanTree(v, "/messages[0]/code", &resultCode)
-	if err != nil {
-		return settings, 3
-	}
-	result, _ = strconv.Atoi(resultCode)
-	_ = scan.ScanTree(v, "/response/cacheSize", &cacheSize)
-	_ = scan.ScanTree(v, "/response/maxFiles", &maxFiles)
-	_ = scan.ScanTree(v, "/response/maxProConnections", &maxProConnections)
-	_ = scan.ScanTree(v, "/response/maxPSOS", &maxPSOS)
+	cacheSize := config.CacheSize
+	maxFiles := config.MaxFiles
+	maxProConnections := config.MaxProConnections
+	maxPSOS := config.MaxPSOS
+	startupRestorationEnabled := false
 	startupRestorationBuiltin := true
-	err = scan.ScanTree(v, "/response/startupRestorationEnabled", &startupRestorationEnabled)
-	if err != nil {
+	if config.StartupRestorationEnabled != nil {
+		startupRestorationEnabled = *config.StartupRestorationEnabled
+	} else {
 		// for Claris FileMaker Server 19.1.2 or later
 		startupRestorationBuiltin = false
 	}
+	onlyOpenLastOpenedDatabases := false
+	if config.OnlyOpenLastOpenedDatabases != nil {
+		onlyOpenLastOpenedDatabases = *config.OnlyOpenLastOpenedDatabases
+	}
 
 	settings = append(settings, cacheSize)
 	settings = append(settings, maxFiles)
@@ -4070,7 +5284,6 @@ func getServerGeneralConfigurations(urlString string, token string, printOptions
 
 	if version >= 21.1 {
 		// for Claris FileMaker Server 21.1.1 or later
-		_ = scan.ScanTree(v, "/response/onlyOpenLastOpenedDatabases", &onlyOpenLastOpenedDatabases)
 		if onlyOpenLastOpenedDatabases {
 			settings = append(settings, 1)
 		} else {
@@ -4079,406 +5292,599 @@ func getServerGeneralConfigurations(urlString string, token string, printOptions
 	}
 
 	// output
-	if result == 0 {
-		for _, option := range printOptions {
-			if option == "maxguests" {
-				fmt.Println("MaxGuests = " + strconv.Itoa(maxProConnections) + " [default: 250, range: 0-2000] ")
-			}
-			if option == "maxfiles" {
-				if version >= 20.1 {
-					fmt.Println("MaxFiles = " + strconv.Itoa(maxFiles) + " [default: 256, range: 1-256] ")
-				} else {
-					fmt.Println("MaxFiles = " + strconv.Itoa(maxFiles) + " [default: 125, range: 1-125] ")
-				}
-			}
-			if option == "cachesize" {
-				fmt.Println("CacheSize = " + strconv.Itoa(cacheSize) + " [default: 512, range: 64-1048576] ")
-			}
-			if option == "hostedfiles" {
-				if version >= 20.1 {
-					fmt.Println("HostedFiles = " + strconv.Itoa(maxFiles) + " [default: 256, range: 1-256] ")
-				} else {
-					fmt.Println("HostedFiles = " + strconv.Itoa(maxFiles) + " [default: 125, range: 1-125] ")
-				}
-			}
-			if option == "proconnections" {
-				fmt.Println("ProConnections = " + strconv.Itoa(maxProConnections) + " [default: 250, range: 0-2000] ")
+	for _, option := range printOptions {
+		if option == "maxguests" {
+			printSetting(c, "MaxGuests", setting{Value: maxProConnections, Default: 250, Range: []int{0, 2000}})
+		}
+		if option == "maxfiles" {
+			if version >= 20.1 {
+				printSetting(c, "MaxFiles", setting{Value: maxFiles, Default: 256, Range: []int{1, 256}})
+			} else {
+				printSetting(c, "MaxFiles", setting{Value: maxFiles, Default: 125, Range: []int{1, 125}})
 			}
-			if option == "scriptsessions" {
-				fmt.Println("ScriptSessions = " + strconv.Itoa(maxPSOS) + " [default: 100, range: 0-500] ")
-			} else if option == "allowpsos" {
-				fmt.Println("AllowPSOS = " + strconv.Itoa(maxPSOS) + " [default: 100, range: 0-500] ")
+		}
+		if option == "cachesize" {
+			printSetting(c, "CacheSize", setting{Value: cacheSize, Default: 512, Range: []int{64, 1048576}})
+		}
+		if option == "hostedfiles" {
+			if version >= 20.1 {
+				printSetting(c, "HostedFiles", setting{Value: maxFiles, Default: 256, Range: []int{1, 256}})
+			} else {
+				printSetting(c, "HostedFiles", setting{Value: maxFiles, Default: 125, Range: []int{1, 125}})
 			}
+		}
+		if option == "proconnections" {
+			printSetting(c, "ProConnections", setting{Value: maxProConnections, Default: 250, Range: []int{0, 2000}})
+		}
+		if option == "scriptsessions" {
+			printSetting(c, "ScriptSessions", setting{Value: maxPSOS, Default: 100, Range: []int{0, 500}})
+		} else if option == "allowpsos" {
+			printSetting(c, "AllowPSOS", setting{Value: maxPSOS, Default: 100, Range: []int{0, 500}})
+		}
 
-			if option == "securefilesonly" || option == "requiresecuredb" {
-				getServerSettingAsBool(strings.Replace(urlString, "/general", "/security", 1), token, []string{option})
-			}
+		if option == "securefilesonly" || option == "requiresecuredb" {
+			getServerSettingAsBool(c, client, "/server/config/security", []string{option})
+		}
 
-			if startupRestorationBuiltin && option == "startuprestorationenabled" {
-				if startupRestorationEnabled {
-					fmt.Println("StartupRestorationEnabled = true [default: true] ")
-				} else {
-					fmt.Println("StartupRestorationEnabled = false [default: true] ")
-				}
-			}
+		if startupRestorationBuiltin && option == "startuprestorationenabled" {
+			printSetting(c, "StartupRestorationEnabled", setting{Value: startupRestorationEnabled, Default: true})
+		}
 
-			if option == "authenticatedstream" {
-				if version >= 19.3 && !strings.HasPrefix(versionString, "19.3.1") {
-					getAuthenticatedStreamSetting(strings.Replace(urlString, "/general", "/authenticatedstream", 1), token, []string{option})
-				}
+		if option == "authenticatedstream" {
+			if version >= 19.3 && !strings.HasPrefix(versionString, "19.3.1") {
+				getAuthenticatedStreamSetting(c, client, []string{option})
 			}
+		}
 
-			if option == "parallelbackupenabled" {
-				if version >= 19.5 {
-					getServerSettingAsBool(strings.Replace(urlString, "/general", "/parallelbackup", 1), token, []string{option})
-				}
+		if option == "parallelbackupenabled" {
+			if version >= 19.5 {
+				getServerSettingAsBool(c, client, "/server/config/parallelbackup", []string{option})
 			}
+		}
 
-			if option == "persistcacheenabled" || option == "syncpersistcache" {
-				if version >= 20.1 {
-					getPersistentCacheConfigurations(strings.Replace(urlString, "/general", "/persistentcache", 1), token, []string{option})
-				}
+		if option == "persistcacheenabled" || option == "syncpersistcache" {
+			if version >= 20.1 {
+				getPersistentCacheConfigurations(c, client, []string{option})
 			}
+		}
 
-			if option == "databaseserverautorestart" {
-				if version >= 21.0 {
-					getPersistentCacheConfigurations(strings.Replace(urlString, "/general", "/persistentcache", 1), token, []string{option})
-				}
+		if option == "databaseserverautorestart" {
+			if version >= 21.0 {
+				getPersistentCacheConfigurations(c, client, []string{option})
 			}
+		}
 
-			if option == "blocknewusersenabled" {
-				if version >= 21.0 {
-					getServerSettingAsBool(strings.Replace(urlString, "/general", "/blocknewusers", 1), token, []string{option})
-				}
+		if option == "blocknewusersenabled" {
+			if version >= 21.0 {
+				getServerSettingAsBool(c, client, "/server/config/blocknewusers", []string{option})
 			}
+		}
 
-			if option == "enablehttpprotocolnetwork" {
-				if version >= 21.1 {
-					getServerSettingAsBool(strings.Replace(urlString, "/server/config/general", "/fmclients/httpstunneling", 1), token, []string{option})
-				}
+		if option == "enablehttpprotocolnetwork" {
+			if version >= 21.1 {
+				getServerSettingAsBool(c, client, "/fmclients/httpstunneling", []string{option})
 			}
+		}
 
-			if option == "onlyopenlastopeneddatabases" {
-				if version >= 21.1 {
-					if onlyOpenLastOpenedDatabases {
-						fmt.Println("OnlyOpenLastOpenedDatabases = true [default: false] ")
-					} else {
-						fmt.Println("OnlyOpenLastOpenedDatabases = false [default: false] ")
-					}
-				}
+		if option == "onlyopenlastopeneddatabases" {
+			if version >= 21.1 {
+				printSetting(c, "OnlyOpenLastOpenedDatabases", setting{Value: onlyOpenLastOpenedDatabases, Default: false})
 			}
 		}
 	}
 
-	return settings, result
+	return settings, 0
 }
 
-func getAuthenticatedStreamSetting(urlString string, token string, printOptions []string) (int, int, error) {
-	var resultCode string
-	var result int
-	var authenticatedStream int
-
-	body, _, err := callURL("GET", urlString, token, nil)
+func getAuthenticatedStreamSetting(c *cli, client *fmsadmin.Client, printOptions []string) (int, int, error) {
+	config, err := client.GetAuthenticatedStreamConfig()
 	if err != nil {
-		return 0, 10502, err
+		result := getExitStatus(err)
+		if result == -1 {
+			return 0, 10502, err
+		}
+		return 0, result, err
 	}
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		fmt.Println(err.Error())
+	// output
+	for _, option := range printOptions {
+		if option == "authenticatedstream" {
+			printSetting(c, "AuthenticatedStream", setting{Value: config.AuthenticatedStream, Default: 1, Range: []int{1, 2}})
+		}
+	}
+
+	return config.AuthenticatedStream, 0, nil
+}
+
+func getServerSettingAsBool(c *cli, client *fmsadmin.Client, endpoint string, printOptions []string) (bool, int, error) {
+	var enabled bool
+	var err error
+
+	switch endpoint {
+	case "/server/config/security":
+		var config *fmsadmin.SecurityConfig
+		config, err = client.GetSecurityConfig()
+		if err == nil {
+			enabled = config.RequireSecureDB
+		}
+	case "/server/config/parallelbackup":
+		var config *fmsadmin.ParallelBackupConfig
+		config, err = client.GetParallelBackupConfig()
+		if err == nil {
+			enabled = config.ParallelBackupEnabled
+		}
+	case "/server/config/blocknewusers":
+		var config *fmsadmin.BlockNewUsersConfig
+		config, err = client.GetBlockNewUsersConfig()
+		if err == nil {
+			enabled = config.BlockNewUsers
+		}
+	case "/fmclients/httpstunneling":
+		var config *fmsadmin.HTTPSTunnelingConfig
+		config, err = client.GetHTTPSTunnelingConfig()
+		if err == nil {
+			enabled = config.EnableHTTPSTunneling
+		}
+	default:
+		return false, 3, fmt.Errorf("%s", "Unknown setting: "+endpoint)
 	}
 
-	err = scan.ScanTree(v, "/messages[0]/code", &resultCode)
 	if err != nil {
-		return 0, 3, err
+		result := getExitStatus(err)
+		if result == -1 {
+			return false, 10502, err
+		}
+		return false, result, err
 	}
-	result, _ = strconv.Atoi(resultCode)
-	err = scan.ScanTree(v, "/response/authenticatedStream", &authenticatedStream)
 
 	// output
-	if result == 0 {
-		for _, option := range printOptions {
-			if option == "authenticatedstream" {
-				fmt.Println("AuthenticatedStream = " + strconv.Itoa(authenticatedStream) + " [default: 1, range: 1-2] ")
-			}
+	for _, option := range printOptions {
+		switch option {
+		case "securefilesonly":
+			printSetting(c, "SecureFilesOnly", setting{Value: enabled, Default: true})
+		case "requiresecuredb":
+			printSetting(c, "RequireSecureDB", setting{Value: enabled, Default: true})
+		case "parallelbackupenabled":
+			printSetting(c, "ParallelBackupEnabled", setting{Value: enabled, Default: false})
+		case "blocknewusersenabled":
+			printSetting(c, "BlockNewUsersEnabled", setting{Value: enabled, Default: false})
+		case "enablehttpprotocolnetwork":
+			printSetting(c, "EnableHttpProtocolNetwork", setting{Value: enabled, Default: false})
+		case "onlyopenlastopeneddatabases":
+			printSetting(c, "OnlyOpenLastOpenedDatabases", setting{Value: enabled, Default: false})
+		default:
 		}
 	}
 
-	return authenticatedStream, result, err
+	return enabled, 0, nil
 }
 
-func getServerSettingAsBool(urlString string, token string, printOptions []string) (bool, int, error) {
-	var resultCode string
+func getWebTechnologyConfigurations(c *cli, client *fmsadmin.Client, printOptions []string) ([]string, int, error) {
+	var settings []string
 	var result int
-	var enabled bool
-	var enabledStr string
+	var enabledPhpStr string
+	var enabledXMLStr string
+	var characterEncoding string
+	var dataPreValidationStr string
+	var errorMessageLanguage string
+	var useFileMakerPhpStr string
 
-	body, _, err := callURL("GET", urlString, token, nil)
+	// get PHP Technology Configuration
+	linux := false
+	phpConfig, err := client.GetPHPConfig()
 	if err != nil {
-		return false, 10502, err
+		var apiErr *fmsadmin.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == 500 {
+			// for Claris FileMaker Server for Linux
+			linux = true
+			phpConfig = &fmsadmin.PHPConfig{}
+		} else if exitStatus := getExitStatus(err); exitStatus == -1 {
+			fmt.Println(err.Error())
+			return settings, 10502, err
+		} else {
+			return settings, exitStatus, err
+		}
 	}
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		fmt.Println(err.Error())
-	}
+	enabledPhpStr = strconv.FormatBool(phpConfig.Enabled)
+	characterEncoding = phpConfig.CharacterEncoding
+	errorMessageLanguage = phpConfig.ErrorMessageLanguage
 
-	err = scan.ScanTree(v, "/messages[0]/code", &resultCode)
-	if err != nil {
-		return false, 3, err
+	if linux {
+		dataPreValidationStr = ""
+		useFileMakerPhpStr = "true"
+	} else {
+		dataPreValidationStr = strconv.FormatBool(phpConfig.DataPreValidation)
+		useFileMakerPhpStr = strconv.FormatBool(phpConfig.UseFileMakerPhp)
 	}
-	result, _ = strconv.Atoi(resultCode)
 
-	u, err := url.Parse(urlString)
+	// get XML Technology Configuration
+	xmlConfig, err := client.GetXMLConfig()
 	if err != nil {
-		return false, 3, err
+		result = getExitStatus(err)
+		if result == -1 {
+			fmt.Println(err.Error())
+		}
+		return settings, result, err
 	}
 
-	if u.Path == path.Join(getAPIBasePath(), "server", "config", "security") {
-		err = scan.ScanTree(v, "/response/requireSecureDB", &enabled)
-	} else if u.Path == path.Join(getAPIBasePath(), "server", "config", "parallelbackup") {
-		err = scan.ScanTree(v, "/response/parallelBackupEnabled", &enabled)
-	} else if u.Path == path.Join(getAPIBasePath(), "server", "config", "blocknewusers") {
-		err = scan.ScanTree(v, "/response/blockNewUsers", &enabled)
-	} else if u.Path == path.Join(getAPIBasePath(), "fmclients", "httpstunneling") {
-		err = scan.ScanTree(v, "/response/enableHTTPSTunneling", &enabled)
-	}
+	enabledXMLStr = strconv.FormatBool(xmlConfig.Enabled)
 
-	enabledStr = "false"
-	if enabled {
-		enabledStr = "true"
-	}
+	settings = append(settings, enabledPhpStr)
+	settings = append(settings, enabledXMLStr)
+	settings = append(settings, characterEncoding)
+	settings = append(settings, errorMessageLanguage)
+	settings = append(settings, dataPreValidationStr)
+	settings = append(settings, useFileMakerPhpStr)
 
 	// output
 	if result == 0 {
 		for _, option := range printOptions {
-			switch option {
-			case "securefilesonly":
-				fmt.Println("SecureFilesOnly = " + enabledStr + " [default: true] ")
-			case "requiresecuredb":
-				fmt.Println("RequireSecureDB = " + enabledStr + " [default: true] ")
-			case "parallelbackupenabled":
-				fmt.Println("ParallelBackupEnabled = " + enabledStr + " [default: false] ")
-			case "blocknewusersenabled":
-				fmt.Println("BlockNewUsersEnabled = " + enabledStr + " [default: false] ")
-			case "enablehttpprotocolnetwork":
-				fmt.Println("EnableHttpProtocolNetwork = " + enabledStr + " [default: false] ")
-			case "onlyopenlastopeneddatabases":
-				fmt.Println("OnlyOpenLastOpenedDatabases = " + enabledStr + " [default: false] ")
-			default:
+			if option == "enablephp" {
+				printSetting(c, "EnablePHP", setting{Value: phpConfig.Enabled})
+			}
+			if option == "enablexml" {
+				printSetting(c, "EnableXML", setting{Value: xmlConfig.Enabled})
+			}
+			if option == "encoding" {
+				printSetting(c, "Encoding", setting{Value: characterEncoding, Options: []string{"UTF-8", "ISO-8859-1"}})
+			}
+			if option == "locale" {
+				printSetting(c, "Locale", setting{Value: errorMessageLanguage, Options: []string{"en", "de", "fr", "it", "ja"}})
+			}
+			if option == "prevalidation" {
+				if linux {
+					// not supported by Claris FileMaker Server for Linux
+					printSetting(c, "PreValidation", setting{})
+				} else {
+					printSetting(c, "PreValidation", setting{Value: phpConfig.DataPreValidation})
+				}
+			}
+			if option == "usefmphp" {
+				printSetting(c, "UseFMPHP", setting{Value: linux || phpConfig.UseFileMakerPhp})
 			}
 		}
 	}
 
-	return enabled, result, err
+	return settings, result, err
 }
 
-func getWebTechnologyConfigurations(baseURI string, basePath string, token string, printOptions []string) ([]string, int, error) {
+func getPersistentCacheConfigurations(c *cli, client *fmsadmin.Client, printOptions []string) ([]string, int, error) {
 	var settings []string
-	var resultCode string
-	var result int
-	var enabledPhp bool
-	var enabledPhpStr string
-	var enabledXML bool
-	var enabledXMLStr string
-	var characterEncoding string
-	var dataPreValidation bool
-	var dataPreValidationStr string
-	var errorMessageLanguage string
-	var useFileMakerPhp bool
-	var useFileMakerPhpStr string
 
-	// get PHP Technology Configuration
-	u, _ := url.Parse(baseURI)
-	u.Path = path.Join(basePath, "php", "config")
+	persistentCacheStr := "false"
+	persistentCacheSyncStr := "false"
+	databaseServerAutoRestartStr := "false"
 
-	body, exitStatus, err := callURL("GET", u.String(), token, nil)
+	config, err := client.GetPersistentCacheConfig()
 	if err != nil {
-		fmt.Println(err.Error())
-		return settings, 10502, err
+		result := getExitStatus(err)
+		if result == -1 {
+			return settings, 10502, err
+		}
+		return settings, result, err
+	}
+
+	if config.PersistentCache {
+		persistentCacheStr = "true"
+	}
+	if config.PersistentCacheSync {
+		persistentCacheSyncStr = "true"
+	}
+	if config.DatabaseServerAutoRestart {
+		databaseServerAutoRestartStr = "true"
 	}
 
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
+	settings = append(settings, persistentCacheStr)
+	settings = append(settings, persistentCacheSyncStr)
+	settings = append(settings, databaseServerAutoRestartStr)
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		return settings, 3, err
+	// output
+	for _, option := range printOptions {
+		if option == "persistcacheenabled" {
+			printSetting(c, "PersistCacheEnabled", setting{Value: config.PersistentCache, Default: false})
+		}
+		if option == "syncpersistcache" {
+			printSetting(c, "SyncPersistCache", setting{Value: config.PersistentCacheSync, Default: false})
+		}
+		if option == "databaseserverautorestart" {
+			printSetting(c, "DatabaseServerAutoRestart", setting{Value: config.DatabaseServerAutoRestart, Default: false})
+		}
 	}
 
-	err = scan.ScanTree(v, "/messages[0]/code", &resultCode)
-	if err != nil {
-		return settings, 3, err
+	return settings, 0, nil
+}
+
+// clientCondition is a condition of the --where option of the DISCONNECT
+// CLIENT and SEND commands (e.g. "ip in 10.2.0.0/16").
+type clientCondition struct {
+	field    string
+	operator string
+	value    string
+	networks []*net.IPNet
+	duration time.Duration
+}
+
+// clientFields are the fields of the conditions and their operators.
+var clientFields = map[string][]string{
+	"user":     {"=", "!="},
+	"account":  {"=", "!="},
+	"privset":  {"=", "!="},
+	"computer": {"=", "!="},
+	"ip":       {"=", "!=", "in", "not in"},
+	"app":      {"=", "!="},
+	"type":     {"=", "!="},
+	"database": {"=", "!="},
+	"duration": {">", ">=", "<", "<="},
+}
+
+// parseClientFilter parses the conditions joined by "and" such as
+// "ip in 10.2.0.0/16 and duration > 8h". Values may be quoted, and the
+// values of text fields may be glob patterns (e.g. "user = 'Guest*'").
+func parseClientFilter(expr string) ([]clientCondition, error) {
+	tokens := regexp.MustCompile(`'[^']*'|"[^"]*"|[<>!=]=|[=<>]|[^\s=!<>]+`).FindAllString(expr, -1)
+	pos := 0
+	next := func() string {
+		if pos >= len(tokens) {
+			return ""
+		}
+		pos++
+		return tokens[pos-1]
+	}
+
+	var conditions []clientCondition
+	for pos < len(tokens) {
+		if len(conditions) > 0 {
+			if token := next(); !strings.EqualFold(token, "and") {
+				return nil, fmt.Errorf("\"and\" expected before \"%s\"", token)
+			}
+		}
+
+		condition := clientCondition{field: strings.ToLower(next()), operator: strings.ToLower(next())}
+		if condition.operator == "not" && strings.EqualFold(next(), "in") {
+			condition.operator = "not in"
+		} else if condition.operator == "==" {
+			condition.operator = "="
+		}
+		operators, ok := clientFields[condition.field]
+		if !ok {
+			return nil, fmt.Errorf("unknown field \"%s\"", condition.field)
+		}
+		valid := false
+		for _, operator := range operators {
+			valid = valid || operator == condition.operator
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid operator for %s: \"%s\"", condition.field, condition.operator)
+		}
+
+		condition.value = next()
+		if len(condition.value) >= 2 && strings.ContainsAny(condition.value[:1], `'"`) {
+			condition.value = condition.value[1 : len(condition.value)-1]
+		}
+		if condition.value == "" {
+			return nil, fmt.Errorf("no value for %s", condition.field)
+		}
+
+		switch condition.field {
+		case "ip":
+			for _, value := range strings.Split(condition.value, ",") {
+				if ip := net.ParseIP(value); ip != nil {
+					// a single address
+					bits := len(ip.To16()) * 8
+					if ip.To4() != nil {
+						bits = 32
+					}
+					value = value + "/" + strconv.Itoa(bits)
+				}
+				_, network, err := net.ParseCIDR(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid IP address \"%s\"", value)
+				}
+				condition.networks = append(condition.networks, network)
+			}
+		case "duration":
+			duration, err := parseDuration(condition.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration \"%s\"", condition.value)
+			}
+			condition.duration = duration
+		case "type":
+			condition.value = strings.ToLower(condition.value)
+			switch condition.value {
+			case "pro", "go", "webdirect", "other":
+			default:
+				return nil, fmt.Errorf("invalid client type \"%s\"", condition.value)
+			}
+		default:
+			if _, err := path.Match(condition.value, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern \"%s\"", condition.value)
+			}
+		}
+		conditions = append(conditions, condition)
 	}
-	_, _ = strconv.Atoi(resultCode)
-	_ = scan.ScanTree(v, "/response/enabled", &enabledPhp)
-	_ = scan.ScanTree(v, "/response/characterEncoding", &characterEncoding)
-	_ = scan.ScanTree(v, "/response/errorMessageLanguage", &errorMessageLanguage)
-	_ = scan.ScanTree(v, "/response/dataPreValidation", &dataPreValidation)
-	_ = scan.ScanTree(v, "/response/useFileMakerPhp", &useFileMakerPhp)
 
-	enabledPhpStr = "true"
-	if !enabledPhp {
-		enabledPhpStr = "false"
+	if len(conditions) == 0 {
+		return nil, errors.New("an expression is required")
 	}
 
-	if exitStatus == 500 {
-		// for Claris FileMaker Server for Linux
-		dataPreValidationStr = ""
-		useFileMakerPhpStr = "true"
-	} else {
-		dataPreValidationStr = "true"
-		if !dataPreValidation {
-			dataPreValidationStr = "false"
-		}
+	return conditions, nil
+}
 
-		useFileMakerPhpStr = "true"
-		if !useFileMakerPhp {
-			useFileMakerPhpStr = "false"
+// matchClient reports whether the client meets all the conditions.
+func matchClient(conditions []clientCondition, v fmsadmin.ConnectedClient) bool {
+	for _, condition := range conditions {
+		if !condition.matches(v) {
+			return false
 		}
 	}
 
-	// get XML Technology Configuration
-	u.Path = path.Join(basePath, "xml", "config")
+	return true
+}
 
-	body, _, err = callURL("GET", u.String(), token, nil)
-	if err != nil {
-		fmt.Println(err.Error())
-		return settings, -1, err
+func (condition clientCondition) matches(v fmsadmin.ConnectedClient) bool {
+	matched := false
+	switch condition.field {
+	case "user":
+		matched = matchText(condition.value, v.UserName)
+	case "computer":
+		matched = matchText(condition.value, v.ComputerName)
+	case "app":
+		matched = matchText(condition.value, v.AppVersion)
+	case "type":
+		matched = condition.value == getClientType(v)
+	case "account":
+		for _, guestFile := range v.GuestFiles {
+			matched = matched || matchText(condition.value, guestFile.AccountName)
+		}
+	case "privset":
+		for _, guestFile := range v.GuestFiles {
+			matched = matched || matchText(condition.value, guestFile.PrivsetName)
+		}
+	case "database":
+		for _, guestFile := range v.GuestFiles {
+			matched = matched || comparePath(condition.value, guestFile.Filename) || matchGlobPattern(condition.value, guestFile.Filename)
+		}
+	case "ip":
+		ip := net.ParseIP(v.IPAddress)
+		for _, network := range condition.networks {
+			matched = matched || (ip != nil && network.Contains(ip))
+		}
+	case "duration":
+		duration, ok := parseConnectDuration(v.ConnectDuration)
+		if !ok {
+			return false
+		}
+		switch condition.operator {
+		case ">":
+			return duration > condition.duration
+		case ">=":
+			return duration >= condition.duration
+		case "<":
+			return duration < condition.duration
+		case "<=":
+			return duration <= condition.duration
+		}
 	}
 
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		fmt.Println(err.Error())
+	if condition.operator == "!=" || condition.operator == "not in" {
+		return !matched
 	}
 
-	err = scan.ScanTree(v, "/messages[0]/code", &resultCode)
-	if err != nil {
-		return settings, 3, err
-	}
-	result, _ = strconv.Atoi(resultCode)
-	err = scan.ScanTree(v, "/response/enabled", &enabledXML)
+	return matched
+}
 
-	enabledXMLStr = "true"
-	if !enabledXML {
-		enabledXMLStr = "false"
+// getClientType returns the type of the client ("pro", "go", "webdirect" or
+// "other") detected from the extended privilege and the app version.
+func getClientType(v fmsadmin.ConnectedClient) string {
+	extPriv := strings.ToLower(v.ExtPriv)
+	appVersion := strings.ToLower(v.AppVersion)
+	if strings.Contains(extPriv, "fmwebdirect") || strings.Contains(appVersion, "webdirect") {
+		return "webdirect"
+	} else if regexp.MustCompile(`\bgo\b`).MatchString(appVersion) {
+		return "go"
+	} else if strings.Contains(appVersion, "pro") {
+		return "pro"
 	}
 
-	settings = append(settings, enabledPhpStr)
-	settings = append(settings, enabledXMLStr)
-	settings = append(settings, characterEncoding)
-	settings = append(settings, errorMessageLanguage)
-	settings = append(settings, dataPreValidationStr)
-	settings = append(settings, useFileMakerPhpStr)
+	return "other"
+}
 
-	// output
-	if result == 0 {
-		for _, option := range printOptions {
-			if option == "enablephp" {
-				fmt.Println("EnablePHP = " + enabledPhpStr)
-			}
-			if option == "enablexml" {
-				fmt.Println("EnableXML = " + enabledXMLStr)
-			}
-			if option == "encoding" {
-				fmt.Println("Encoding = " + characterEncoding + " [ UTF-8 ISO-8859-1 ]")
-			}
-			if option == "locale" {
-				fmt.Println("Locale = " + errorMessageLanguage + " [ en de fr it ja ]")
-			}
-			if option == "prevalidation" {
-				fmt.Println("PreValidation = " + dataPreValidationStr)
-			}
-			if option == "usefmphp" {
-				fmt.Println("UseFMPHP = " + useFileMakerPhpStr)
-			}
-		}
+// matchText reports whether the text equals the pattern or matches it as a
+// glob pattern ignoring case. The pattern is compared as it is first, since
+// names like "[Full Access]" look like glob patterns.
+func matchText(pattern string, text string) bool {
+	if strings.EqualFold(pattern, text) {
+		return true
 	}
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(text))
 
-	return settings, result, err
+	return matched
 }
 
-func getPersistentCacheConfigurations(urlString string, token string, printOptions []string) ([]string, int, error) {
-	var settings []string
-	var resultCode string
-	var result int
-	var persistentCache bool
-	var persistentCacheSync bool
-	var databaseServerAutoRestart bool
-
-	persistentCacheStr := "false"
-	persistentCacheSyncStr := "false"
-	databaseServerAutoRestartStr := "false"
-
-	body, _, err := callURL("GET", urlString, token, nil)
-	if err != nil {
-		return settings, 10502, err
+// parseConnectDuration parses the connection duration of a client in the
+// form of "H:MM:SS" or "D:HH:MM:SS".
+func parseConnectDuration(s string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 3 || len(parts) > 4 {
+		return 0, false
 	}
 
-	var v interface{}
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		fmt.Println(err.Error())
+	units := []time.Duration{time.Second, time.Minute, time.Hour, 24 * time.Hour}
+	duration := time.Duration(0)
+	for i := 0; i < len(parts); i++ {
+		n, err := strconv.Atoi(parts[len(parts)-1-i])
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		duration += time.Duration(n) * units[i]
 	}
 
-	err = scan.ScanTree(v, "/messages[0]/code", &resultCode)
+	return duration, true
+}
+
+// disconnectMatchingClients lists the clients matching the conditions and
+// disconnects them after the confirmation.
+func disconnectMatchingClients(c *cli, client *fmsadmin.Client, conditions []clientCondition, message string, graceTime int, yesFlag bool) int {
+	clients, err := client.ListClients()
 	if err != nil {
-		return settings, 3, err
+		exitStatus := getExitStatus(err)
+		if exitStatus == -1 || exitStatus == 3 {
+			fmt.Fprintln(c.outStream, err.Error())
+		}
+		return exitStatus
 	}
-	result, _ = strconv.Atoi(resultCode)
-	_ = scan.ScanTree(v, "/response/persistentCache", &persistentCache)
-	_ = scan.ScanTree(v, "/response/persistentCacheSync", &persistentCacheSync)
-	_ = scan.ScanTree(v, "/response/databaseServerAutoRestart", &databaseServerAutoRestart)
 
-	if persistentCache {
-		persistentCacheStr = "true"
-	}
-	if persistentCacheSync {
-		persistentCacheSyncStr = "true"
+	var idList []int
+	var data [][]string
+	for _, v := range clients {
+		if v.Status == "NORMAL" && matchClient(conditions, v) {
+			idList = append(idList, v.ID)
+			data = append(data, []string{strconv.Itoa(v.ID), v.UserName, v.ComputerName, v.IPAddress, v.ConnectDuration, v.AppVersion})
+		}
 	}
-	if databaseServerAutoRestart {
-		databaseServerAutoRestartStr = "true"
+	if len(idList) == 0 {
+		return 11005
 	}
 
-	settings = append(settings, persistentCacheStr)
-	settings = append(settings, persistentCacheSyncStr)
-	settings = append(settings, databaseServerAutoRestartStr)
+	fmt.Fprintln(c.outStream, "Matching client(s):")
+	outputTable(c, []string{"Client ID", "User Name", "Computer Name", "IP Address", "Duration", "App Version"}, data)
+	if !yesFlag {
+		r := bufio.NewReader(os.Stdin)
+		fmt.Fprint(c.outStream, "fmcsadmin: really disconnect client(s)? (y, n) ")
+		input, _ := r.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(input)) != "y" {
+			return 0
+		}
+	}
 
-	// output
-	if result == 0 {
-		for _, option := range printOptions {
-			if option == "persistcacheenabled" {
-				fmt.Println("PersistCacheEnabled = " + persistentCacheStr + " [default: false] ")
-			}
-			if option == "syncpersistcache" {
-				fmt.Println("SyncPersistCache = " + persistentCacheSyncStr + " [default: false] ")
-			}
-			if option == "databaseserverautorestart" {
-				fmt.Println("DatabaseServerAutoRestart = " + databaseServerAutoRestartStr + " [default: false] ")
-			}
+	exitStatus := 0
+	for i := 0; i < len(idList); i++ {
+		exitStatus = getExitStatus(client.DisconnectClient(idList[i], message, graceTime))
+		if exitStatus == -1 {
+			break
 		}
 	}
+	if exitStatus == 0 {
+		fmt.Fprintln(c.outStream, "Client(s) being disconnected.")
+	}
 
-	return settings, result, err
+	return exitStatus
 }
 
-func disconnectAllClient(u *url.URL, token string, message string, graceTime int) (int, error) {
+func disconnectAllClient(client *fmsadmin.Client, message string, graceTime int) (int, error) {
 	exitStatus := 0
 	var err error
 
 	// check the client connection
-	u.Path = path.Join(getAPIBasePath(), "clients")
-	idList := getClients(u.String(), token, []string{""})
+	idList := getClients(client, []string{""})
 
 	// disconnect clients
 	if len(idList) > 0 {
 		for i := 0; i < len(idList); i++ {
-			u.Path = path.Join(getAPIBasePath(), "clients", strconv.Itoa(idList[i]))
-			u.RawQuery = "messageText=" + url.QueryEscape(message) + "&graceTime=" + url.QueryEscape(strconv.Itoa(graceTime))
-			exitStatus, _, err = sendRequest("DELETE", u.String(), token, params{command: "disconnect"})
-			if err != nil {
+			err = client.DisconnectClient(idList[i], message, graceTime)
+			exitStatus = getExitStatus(err)
+			if exitStatus == -1 {
 				break
 			}
 		}
@@ -4487,24 +5893,20 @@ func disconnectAllClient(u *url.URL, token string, message string, graceTime int
 	return exitStatus, err
 }
 
-func stopDatabaseServer(u *url.URL, token string, message string, graceTime int) (int, error) {
-	exitStatus := -1
+func stopDatabaseServer(client *fmsadmin.Client, message string, graceTime int) (int, error) {
 	forceFlag := false
-	var err error
 
 	// disconnect clients
-	_, _ = disconnectAllClient(u, token, message, graceTime)
+	_, _ = disconnectAllClient(client, message, graceTime)
 
 	// close databases
-	u.Path = path.Join(getAPIBasePath(), "databases")
-	idList, _, _ := getDatabases(u.String(), token, []string{""}, "NORMAL", false)
+	idList, _, _ := getDatabases(client, []string{""}, "NORMAL", false)
 	if len(idList) > 0 {
 		for i := 0; i < len(idList); i++ {
-			u.Path = path.Join(getAPIBasePath(), "databases", strconv.Itoa(idList[i]))
 			if graceTime == 0 {
 				forceFlag = true
 			}
-			_, _, _ = sendRequest("PATCH", u.String(), token, params{command: "close", messageText: message, force: forceFlag})
+			_ = client.CloseDatabase(idList[i], message, forceFlag)
 		}
 	}
 
@@ -4512,21 +5914,19 @@ func stopDatabaseServer(u *url.URL, token string, message string, graceTime int)
 	for value := 0; ; {
 		time.Sleep(1 * time.Second)
 		value++
-		u.Path = path.Join(getAPIBasePath(), "databases")
-		openedID, _, _ = getDatabases(u.String(), token, []string{""}, "CLOSING", false)
+		openedID, _, _ = getDatabases(client, []string{""}, "CLOSING", false)
 		if len(openedID) == 0 || value > 120 {
 			break
 		}
 	}
 
 	// stop database server
-	u.Path = path.Join(getAPIBasePath(), "server", "status")
-	exitStatus, _, err = sendRequest("PATCH", u.String(), token, params{status: "STOPPED"})
+	err := client.SetServerStatus("STOPPED")
 
-	return exitStatus, err
+	return getExitStatus(err), err
 }
 
-func waitStoppingServer(u *url.URL, token string) (int, error) {
+func waitStoppingServer(client *fmsadmin.Client) (int, error) {
 	exitStatus := 0
 	var err error
 	var running string
@@ -4534,8 +5934,8 @@ func waitStoppingServer(u *url.URL, token string) (int, error) {
 	for value := 0; ; {
 		time.Sleep(1 * time.Second)
 		value++
-		u.Path = path.Join(getAPIBasePath(), "server", "status")
-		exitStatus, running, err = sendRequest("GET", u.String(), token, params{})
+		running, err = client.ServerStatus()
+		exitStatus = getExitStatus(err)
 		if running == "STOPPED" || value > 120 {
 			break
 		}
@@ -4544,109 +5944,45 @@ func waitStoppingServer(u *url.URL, token string) (int, error) {
 	return exitStatus, err
 }
 
-func getBackupTime(urlString string, token string, id int) int {
-	body, _, err := callURL("GET", urlString, token, nil)
+func getBackupTime(c *cli, client *fmsadmin.Client, id int) int {
+	schedules, err := client.ListSchedules()
 	if err != nil {
-		fmt.Println(err.Error())
-		return -1
+		exitStatus := getExitStatus(err)
+		if exitStatus == -1 || exitStatus == 3 {
+			fmt.Println(err.Error())
+		}
+		return exitStatus
 	}
 
-	var v interface{}
-	body2 := []byte(body)
-	err = json.Unmarshal(body2, &v)
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-
-	var count int
-	var c []string
-	var s1 string
-	var sID int
-	var name string
-	var taskType string
-	var backupType string
-	var filemakerScriptType string
-	var messageType string
-	var scriptSequenceType string
-	var systemScriptType string
-	var verifyType string
-	var nextRun string
-	var enabled bool
-	var status string
-	var data [][]string
+	if c.output == "json" || c.format != "" {
+		infoList := []scheduleInfo{}
+		for _, v := range schedules {
+			if (id == v.ID || id == 0) && v.TaskType() == "Backup" {
+				infoList = append(infoList, newScheduleInfo(v))
+			}
+		}
+		if len(schedules) > 0 && len(infoList) == 0 {
+			return 10600
+		}
+		return outputItems(c, "schedules", infoList)
+	}
 
-	_ = scan.ScanTree(v, "/response/schedules", &c)
-	count = len(c)
+	var data [][]string
 
-	if count > 0 {
-		for i := 0; i < count; i++ {
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/id", &s1)
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/name", &name)
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/backupType/resourceType", &backupType)
-			if err != nil {
-				backupType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/filemakerScriptType/resource", &filemakerScriptType)
-			if err != nil {
-				filemakerScriptType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/messageType/resourceType", &messageType)
-			if err != nil {
-				messageType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/scriptSequenceType/resource", &scriptSequenceType)
-			if err != nil {
-				scriptSequenceType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/systemScriptType/osScript", &systemScriptType)
-			if err != nil {
-				systemScriptType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/verifyType/resourceType", &verifyType)
-			if err != nil {
-				verifyType = ""
-			}
-			err = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/nextRun", &nextRun)
-			if err != nil {
-				nextRun = ""
-			}
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/enabled", &enabled)
-			if !enabled {
-				nextRun = "Disabled"
-			}
-			_ = scan.ScanTree(v, "/response/schedules["+strconv.Itoa(i)+"]/status", &status)
-
-			sID, _ = strconv.Atoi(s1)
-			if id == sID || id == 0 {
-				if backupType != "" {
-					taskType = "Backup"
-				} else if filemakerScriptType != "" {
-					taskType = "FileMaker Script"
-				} else if messageType != "" {
-					taskType = "Message"
-				} else if scriptSequenceType != "" {
-					taskType = "Script Sequence"
-				} else if systemScriptType != "" {
-					taskType = "System Script"
-				} else if verifyType != "" {
-					taskType = "Verify"
+	if len(schedules) > 0 {
+		for _, v := range schedules {
+			if (id == v.ID || id == 0) && v.TaskType() == "Backup" {
+				nextRun := v.NextRun
+				if !v.Enabled {
+					nextRun = "Disabled"
 				}
 				nextRun = getDateTimeStringOfCurrentTimeZone(nextRun, "15:04", false)
-				if taskType == "Backup" {
-					data = append(data, []string{s1, name, nextRun})
-				}
+				data = append(data, []string{strconv.Itoa(v.ID), v.Name, nextRun})
 			}
 		}
 
 		if len(data) > 0 {
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetAutoWrapText(false)
-			table.SetAutoFormatHeaders(false)
-			for _, v := range data {
-				table.SetHeader([]string{"ID", "Name", "Start time"})
-				table.Append(v)
-			}
-			table.Render()
+			outputTable(c, []string{"ID", "Name", "Start time"}, data)
 		} else {
 			return 10600
 		}
@@ -4728,280 +6064,192 @@ func comparePath(name1 string, name2 string) bool {
 	return false
 }
 
-func outputErrorMessage(code int, c *cli) {
-	if code >= -1 {
-		if code == 1701 {
-			// when fmserverd is stopping
-			code = 10502
-		}
-		fmt.Fprintln(c.outStream, "Error: "+strconv.Itoa(code)+" ("+getErrorDescription(code)+")")
-	}
+func newClientInfo(v fmsadmin.ConnectedClient) clientInfo {
+	info := clientInfo{
+		ID:              v.ID,
+		UserName:        v.UserName,
+		ComputerName:    v.ComputerName,
+		ExtPrivilege:    v.ExtPriv,
+		IPAddress:       v.IPAddress,
+		MACAddress:      v.MACAddress,
+		ConnectTime:     v.ConnectTime,
+		ConnectDuration: v.ConnectDuration,
+		AppVersion:      v.AppVersion,
+		AppLanguage:     v.AppLanguage,
+		GuestFiles:      []guestFileInfo{},
+	}
+	for _, guestFile := range v.GuestFiles {
+		info.GuestFiles = append(info.GuestFiles, guestFileInfo(guestFile))
+	}
+
+	return info
 }
 
-func sendRequest(method string, urlString string, token string, p params) (int, string, error) {
-	var jsonStr []byte
-	code := -1
-
-	if ((params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "open") || len(p.key) > 0 {
-		d := dbInfo{
-			"OPENED",
-			p.key,
-			p.saveKey,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "close" {
-		d := closeMessageInfo{
-			"CLOSED",
-			p.messageText,
-			p.force,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && (p.command == "resume" || p.command == "pause") {
-		if p.command == "resume" {
-			d := statusInfo{
-				"RESUMED",
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if p.command == "pause" {
-			d := statusInfo{
-				"PAUSED",
-			}
-			jsonStr, _ = json.Marshal(d)
-		}
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "enable" {
-		d := scheduleSettingInfo{
-			true,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "disable" {
-		d := scheduleSettingInfo{
-			false,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "set" {
-		if strings.HasSuffix(urlString, "/server/config/authenticatedstream") {
-			// for Claris FileMaker Server 19.3.2 or later
-			d := authenticatedStreamConfigInfo{
-				p.authenticatedstream,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/parallelbackup") {
-			// for Claris FileMaker Server 19.5.1 or later
-			parallelbackupenabled := false
-			if p.parallelbackupenabled == "true" {
-				parallelbackupenabled = true
-			}
-			d := parallelBackupConfigInfo{
-				parallelbackupenabled,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/persistentcache") {
-			// for Claris FileMaker Server 21.0.1 or later
-			persistcacheenabled := false
-			syncpersistcache := false
-			databaseserverautorestart := false
-			if p.persistcacheenabled == "true" {
-				persistcacheenabled = true
-			}
-			if p.syncpersistcache == "true" {
-				// the value of PersistCacheEnabled must be true
-				syncpersistcache = true
-			}
-			if p.databaseserverautorestart == "true" {
-				// the value of PersistCacheEnabled must be true
-				databaseserverautorestart = true
-			}
-			d := persistentCacheConfigInfo{
-				persistcacheenabled,
-				syncpersistcache,
-				databaseserverautorestart,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/blocknewusers") {
-			// for Claris FileMaker Server 21.0.1 or later
-			blocknewusersenabled := false
-			if p.blocknewusersenabled == "true" {
-				blocknewusersenabled = true
-			}
-			d := blockNewUsersConfigInfo{
-				blocknewusersenabled,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/fmclients/httpstunneling") {
-			// for Claris FileMaker Server 21.1.1 or later
-			enablehttpprotocolnetwork := false
-			if p.enablehttpprotocolnetwork == "true" {
-				enablehttpprotocolnetwork = true
-			}
-			d := enableHttpProtocolNetworkConfigInfo{
-				enablehttpprotocolnetwork,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/general") && p.onlyopenlastopeneddatabases != "" {
-			// for Claris FileMaker Server 21.1.1 or later
-			onlyopenlastopeneddatabases := false
-			if p.onlyopenlastopeneddatabases == "true" {
-				onlyopenlastopeneddatabases = true
-			}
-			d := general211ConfigInfo{
-				p.cachesize,
-				p.maxfiles,
-				p.maxproconnections,
-				p.maxpsos,
-				onlyopenlastopeneddatabases,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/general") && p.startuprestorationbuiltin {
-			// for Claris FileMaker Server 19.1.1 or previous
-			d := generalOldConfigInfo{
-				p.cachesize,
-				p.maxfiles,
-				p.maxproconnections,
-				p.maxpsos,
-				p.startuprestorationenabled,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/general") && !p.startuprestorationbuiltin {
-			// for Claris FileMaker Server 19.1.2 or later
-			d := generalConfigInfo{
-				p.cachesize,
-				p.maxfiles,
-				p.maxproconnections,
-				p.maxpsos,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/server/config/security") {
-			requiresecuredb := true
-			if p.requiresecuredb == "false" {
-				requiresecuredb = false
-			}
-			d := securityConfigInfo{
-				requiresecuredb,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/php/config") {
-			enabled := true
-			if p.enabled == "false" {
-				enabled = false
-			}
-			d := phpConfigInfo{
-				enabled,
-				p.characterencoding,
-				p.errormessagelanguage,
-				p.dataprevalidation,
-				p.usefilemakerphp,
-			}
-			jsonStr, _ = json.Marshal(d)
-		} else if strings.HasSuffix(urlString, "/xml/config") {
-			enabled := true
-			if p.enabled == "false" {
-				enabled = false
-			}
-			d := xmlConfigInfo{
-				enabled,
-			}
-			jsonStr, _ = json.Marshal(d)
-		}
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "certificate create" {
-		d := creatingCsrInfo{
-			p.subject,
-			p.password,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else if (params{}) != p && reflect.ValueOf(p.command).IsValid() && p.command == "certificate import" {
-		d := importingCertificateInfo{
-			p.certificate,
-			p.privateKey,
-			p.intermediateCertificates,
-			p.password,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else if (params{}) != p && reflect.ValueOf(p.status).IsValid() && (p.status == "RUNNING" || p.status == "STOPPED") {
-		d := statusInfo{
-			p.status,
-		}
-		jsonStr, _ = json.Marshal(d)
-	} else {
-		jsonStr = []byte("")
+func newFileInfo(v fmsadmin.Database) fileInfo {
+	info := fileInfo{
+		ID:                   v.ID,
+		Filename:             v.Filename,
+		Folder:               v.Folder,
+		Status:               v.Status,
+		Clients:              v.Clients,
+		Size:                 v.Size,
+		IsEncrypted:          v.IsEncrypted,
+		DecryptHint:          v.DecryptHint,
+		EnabledExtPrivileges: v.EnabledExtPrivileges,
 	}
-
-	body, statusCode, err := callURL(method, urlString, token, bytes.NewBuffer([]byte(jsonStr)))
-	if err != nil {
-		return -1, "", err
+	if info.EnabledExtPrivileges == nil {
+		info.EnabledExtPrivileges = []string{}
 	}
 
-	// for debugging
-	/*
-		fmt.Println(method)
-		fmt.Println(urlString)
-		fmt.Println(statusCode)
-		fmt.Println(string(jsonStr))
-		fmt.Println(bytes.NewBuffer([]byte(body)))
-	*/
+	return info
+}
 
-	if statusCode >= 400 {
-		return 10001, "", err
+func newScheduleInfo(v fmsadmin.Schedule) scheduleInfo {
+	return scheduleInfo{
+		ID:      v.ID,
+		Name:    v.Name,
+		Type:    v.TaskType(),
+		LastRun: v.LastRun,
+		NextRun: v.NextRun,
+		Enabled: v.Enabled,
+		Status:  v.Status,
 	}
+}
 
-	if body != nil {
-		output := output{}
-		if json.Unmarshal(body, &output) == nil {
-			for i := 0; i < len(output.Messages); i++ {
-				if reflect.ValueOf(output.Messages[i].Code).IsValid() {
-					code, _ = strconv.Atoi(output.Messages[i].Code)
-					break
-				}
-			}
-			return code, output.Response.Status, nil
-		} else {
-			// In case of detecting a server-side error
-			return 3, "", err
+func outputTable(c *cli, header []string, data [][]string) {
+	switch c.output {
+	case "csv", "tsv":
+		w := csv.NewWriter(c.outStream)
+		if c.output == "tsv" {
+			w.Comma = '\t'
+		}
+		if !c.noHeaders {
+			_ = w.Write(header)
+		}
+		_ = w.WriteAll(data)
+	default:
+		table := tablewriter.NewWriter(c.outStream)
+		if !c.noHeaders {
+			table.SetHeader(header)
+		}
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(false)
+		for _, v := range data {
+			table.Append(v)
 		}
+		table.Render()
+	}
+}
+
+// outputItems outputs the items as a JSON document, or outputs each item with
+// the template specified by --format.
+func outputItems(c *cli, name string, items interface{}) int {
+	if c.format != "" {
+		return outputTemplate(c, "{{range .}}{{template \"format\" .}}\n{{end}}", items)
 	}
 
-	return 0, "", nil
+	outputJSON(c, map[string]interface{}{name: items})
+
+	return 0
 }
 
-func callURL(method string, urlString string, token string, request io.Reader) ([]byte, int, error) {
-	req, err := http.NewRequest(method, urlString, request)
-	if err != nil {
-		fmt.Println(err.Error())
-		return []byte(""), 500, err
+func parseTemplate(format string, text string) (*template.Template, error) {
+	// accept escape sequences like "docker ps --format"
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	funcMap := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
 	}
 
-	if request == nil {
-		req.Header.Set("Content-Length", "0")
+	tmpl, err := template.New("format").Funcs(funcMap).Parse(format)
+	if err != nil {
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	if len(token) >= 5 && (token[:5] == "FMID " || token[:6] == "Basic " || token[:4] == "PKI ") {
-		req.Header.Set("Authorization", token)
-	} else {
-		req.Header.Set("Authorization", "Bearer "+strings.Replace(strings.Replace(token, "\n", "", -1), "\r", "", -1))
+
+	return tmpl.New("output").Parse(text)
+}
+
+func outputTemplate(c *cli, text string, data interface{}) int {
+	tmpl, err := parseTemplate(c.format, text)
+	if err == nil {
+		err = tmpl.Execute(c.outStream, data)
 	}
-	client := &http.Client{Timeout: time.Duration(5) * time.Second}
-	res, err := client.Do(req)
 	if err != nil {
-		// for debugging
-		//fmt.Println(err.Error())
+		fmt.Fprintln(c.outStream, err.Error())
+		return 10001
+	}
+
+	return 0
+}
+
+func outputJSON(c *cli, v interface{}) {
+	encoder := json.NewEncoder(c.outStream)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func printSetting(c *cli, name string, s setting) {
+	if c.output == "json" || c.format != "" {
+		// output as a document at the end of the command
+		if c.settings == nil {
+			c.settings = map[string]setting{}
+		}
+		c.settings[name] = s
+		return
+	}
 
-		if res == nil {
-			return []byte(""), 404, err
+	line := name + " = "
+	if s.Value != nil {
+		line += fmt.Sprint(s.Value)
+	}
+	if s.Default != nil {
+		line += " [default: " + fmt.Sprint(s.Default)
+		if len(s.Range) == 2 {
+			line += ", range: " + strconv.Itoa(s.Range[0]) + "-" + strconv.Itoa(s.Range[1])
 		}
+		line += "] "
+	}
+	if len(s.Options) > 0 {
+		line += " [ " + strings.Join(s.Options, " ") + " ]"
+	}
+	fmt.Fprintln(c.outStream, line)
+}
 
-		return []byte(""), res.StatusCode, err
+func outputErrorMessage(code int, c *cli) {
+	if code >= -1 {
+		if code == 1701 {
+			// when fmserverd is stopping
+			code = 10502
+		}
+		fmt.Fprintln(c.outStream, "Error: "+strconv.Itoa(code)+" ("+getErrorDescription(code)+")")
 	}
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+}
 
-	// for debugging
-	//fmt.Println(bytes.NewBuffer([]byte(body)))
+// dryRunStatus is the exit status of a request not sent with --dry-run. It
+// skips the messages of successful commands without printing an error.
+const dryRunStatus = -2
 
-	if err != nil {
-		fmt.Println(err.Error())
-		return []byte(""), res.StatusCode, err
+func getExitStatus(err error) int {
+	var apiErr *fmsadmin.Error
+
+	if err == nil {
+		return 0
+	} else if errors.As(err, &apiErr) {
+		if apiErr.StatusCode >= 400 {
+			return 10001
+		}
+		return apiErr.Code
+	} else if errors.Is(err, fmsadmin.ErrInvalidResponse) {
+		// In case of detecting a server-side error
+		return 3
+	} else if errors.Is(err, fmsadmin.ErrDryRun) {
+		return dryRunStatus
 	}
 
-	return body, res.StatusCode, err
+	return -1
 }
 
 func detectHostUnreachable(exitStatus int) bool {
@@ -5208,8 +6456,11 @@ var commandListHelpTextTemplate = `fmcsadmin commands are:
                     the start time of a backup schedule or schedules
     HELP            Get help pages
     LIST            List clients, databases, plug-ins, or schedules
+    LOGIN           Log in to the server and keep the session for other commands
+    LOGOUT          Log out of the session kept by the LOGIN command
     OPEN            Open databases
     PAUSE           Temporarily stop database access
+    PKI             Generate and test keys for PKI Authentication
     REMOVE          Move databases out of hosted folder
                     (for FileMaker Server 19.3.1 or later)
     RESTART         Restart a server process (for FileMaker Server)
@@ -5248,29 +6499,122 @@ Parameters:
     documentation for your shell or command interpreter.
 
 General Options: 
+    --cacert CAFILE            Verify the server certificate with the CA
+                               certificate(s) in CAFILE (PEM format).
+    --cert CERTFILE            Specify the client certificate (PEM format) for
+                               mutual TLS authentication.
+    --cert-key KEYFILE         Specify the private key of the client
+                               certificate (PEM format).
+    --credential-helper CMD    Specify a credential helper command to get the
+                               password before prompting for it.
+    --dry-run                  Log in and select the targets of the command,
+                               then print the method, the path and the JSON 
+                               body of each request that would change the 
+                               server instead of sending it. Passwords in the
+                               bodies are printed as they are.
     --fqdn                     Specify the Fully Qualified Domain Name (FQDN)
-                               of a remote server via HTTPS.
+                               of a remote server via HTTPS. A port number can
+                               be appended (e.g. fms.example.com:8443).
     -h, --help                 Print this page.
     -i IDENTITYFILE            Specify a private key file for PKI Authentication.
+                               An RSA private key in PKCS #1 or PKCS #8 PEM 
+                               format (optionally encrypted) is supported.
+                               "agent:NAME" uses the RSA key of ssh-agent
+                               whose comment, file name or SHA256 fingerprint
+                               is NAME ("agent:" for the only RSA key).
+    --insecure                 Do not verify the server certificate. This is 
+                               NOT secure and should be used only for testing.
+    --key-name NAME            Specify the name of the public key on Admin
+                               Console for PKI Authentication. The default is
+                               the file name of IDENTITYFILE without the
+                               extension, with "_" replaced by " ".
+    -o FORMAT, --output FORMAT Specify the output format of LIST, STATUS and
+                               GET commands ("table", "json", "csv" or "tsv").
     -p pass, --password pass   Password to use to authenticate with the server.
+    --password-file FILE       Read the password from the first line of FILE
+                               ("-" for the standard input) instead of -p.
+    --password-stdin           Read the password from the standard input.
+    --pin-sha256 HASH          Accept only the server certificate whose public
+                               key has the base64-encoded SHA-256 HASH
+                               ("sha256//HASH"). Separate multiple hashes
+                               with ";".
+    --profile NAME             Use the server profile NAME in the configuration
+                               file (~/.config/fmcsadmin/config.yaml). The
+                               FMCSADMIN_PROFILE environment variable also
+                               selects a profile. Command-line options
+                               override the values of the profile.
+    --proxy URL                Connect to the server through the HTTP proxy
+                               (http://host:port, HTTP CONNECT for HTTPS) or 
+                               the SOCKS5 proxy (socks5://host:port).
+    --retries NUM              Retry a failed request that reads data up to NUM
+                               times, waiting 1, 2, 4, ... seconds (at most 30
+                               seconds) between attempts, when the server is 
+                               unreachable, busy or stopping. The default is 0.
+    --signer-command CMD       Sign the JSON Web Token for PKI Authentication
+                               with CMD instead of a private key file. CMD
+                               reads the signing input from the standard input
+                               and prints the RS256 signature in binary or 
+                               base64. --key-name is required.
+    --ssh-jump [user@]host[:port]
+                               Connect to the server through an SSH connection
+                               to the bastion host. The keys of ssh-agent and
+                               ~/.ssh are used, and the host key must be in
+                               ~/.ssh/known_hosts.
+    --timeout sec              Specify the time limit of each request to the
+                               server in seconds (0 for no limit). The default
+                               is 5 seconds.
+    --token-lifetime DURATION  Specify the lifetime of the JSON Web Token for 
+                               PKI Authentication (e.g. 5m or 300 for 5 
+                               minutes). The default is 15m.
     -u user, --username user   Username to use to authenticate with the server.
+    --url URL                  Specify the URL of the server with the scheme,
+                               the port and the path prefix of a reverse proxy
+                               (e.g. https://proxy.example.com:8443/fms-admin or
+                               http://127.0.0.1:16002). Admin API paths are 
+                               appended to the URL.
     -v, --version              Print version information.
     -y, --yes                  Automatically answer yes to all command prompts.
 
 Options that apply to specific commands:
     -c NUM, --client NUM       Specify a client number to send a message.
+    --drain                    Close databases after their clients disconnect,
+                               blocking new users.
+    --drain-reminder DURATION  Specify the interval of the reminders of 
+                               --drain (e.g. 5m).
+    --drain-timeout DURATION   Specify the time limit of --drain (e.g. 15m).
+    --exclude PATTERN          Do not select databases whose file names match
+                               the glob PATTERN.
     -f, --force                Force database to close or Database Server 
                                to stop, immediately disconnecting clients.
+    --format TEMPLATE          Format the output of LIST, STATUS and GET
+                               commands using the Go template.
     --intermediateCA IMCAFILE  Specify the file that contains the intermediate
                                CA certificate(s) for certificate import.
     --key encryptpass          Specify the database encryption password.
+    --key-file-for-db FILE     Read the database encryption password from the
+                               first line of FILE ("-" for the standard input)
+                               instead of --key.
     --keyfile KEYFILE          Specify private key file for certificate import.
     --keyfilepass kfpassword   Specify password needed to read KEYFILE.
+    --keyfilepass-file FILE    Read the password needed to read KEYFILE from
+                               the first line of FILE ("-" for the standard
+                               input) instead of --keyfilepass.
+    --keymap KEYMAPFILE        Specify the JSON file of the encryption 
+                               passwords of databases to open.
+    --match REGEXP             Select only databases whose file names match
+                               the regular expression REGEXP.
     -m msg, --message msg      Specify a text message to send to clients. 
+    --no-headers               Do not print the header row of tables.
     -s, --stats                Return FILE or CLIENT stats.
     --savekey                  Save the database encryption password.
     -t sec, --gracetime sec    Specify time in seconds before client is forced
                                to disconnect.
+    --wait                     Wait until the databases are opened, closed, 
+                               paused or resumed.
+    --wait-timeout DURATION    Specify the time limit of --wait (e.g. 10m).
+    --where CONDITIONS         Select clients to disconnect or to send a 
+                               message by conditions (e.g. 'ip in 
+                               10.2.0.0/16 and duration > 8h').
 `
 
 var cancelHelpTextTemplate = `Usage: fmcsadmin CANCEL [TYPE]
@@ -5311,6 +6655,11 @@ Options:
         Specifies the encryption password used to encrypt and decrypt the
         private key file.
 
+    --keyfilepass-file FILE
+        Reads the encryption password of the private key file from the first
+        line of FILE ("-" for the standard input) instead of the
+        --keyfilepass option.
+
     --intermediateCA intermediateCAfile
         Specifies the file that contains the intermediate CA certificate(s).
         If the certificate was signed by an intermediate certificate authority,
@@ -5328,6 +6677,12 @@ Description:
     To specify a database by its ID rather than its filename, first use the 
     LIST FILES -s command to get a list of databases and their IDs.
 
+    FILE can also be a glob pattern of file names such as "Invoices_*". 
+    Quote the pattern to prevent the shell from expanding it. A FILE that 
+    is the name of a database (e.g. "Sales [2024]") is not a pattern.
+    The databases selected by patterns, --match or --exclude are listed 
+    before the confirmation.
+
 Options:
     -m message, --message message 
         Specifies a text message to be sent to the clients that are being 
@@ -5335,6 +6690,43 @@ Options:
 
     -f, --force 
         Forces a database to be closed, immediately disconnecting clients.
+
+    --drain
+        Blocks new users, and closes each database after the clients 
+        connected to it disconnect. The clients are reminded to close the 
+        database at the interval of the --drain-reminder option with the 
+        message of the -m option (or a default message). When the time 
+        limit of the --drain-timeout option expires, the remaining databases 
+        are closed as without --drain. New users are allowed again after 
+        draining, or when the command is interrupted (e.g. Ctrl+C). If the 
+        command is killed, allow new users with: 
+          fmcsadmin SET serverprefs blocknewusersenabled=false
+
+    --drain-timeout DURATION
+        Specifies the time limit of the --drain option (e.g. 15m or 900 for 
+        15 minutes). The default is 15m.
+
+    --drain-reminder DURATION
+        Specifies the interval of the reminder messages of the --drain 
+        option (e.g. 5m or 300 for 5 minutes). The default is 5m.
+
+    --wait
+        Waits until each database is closed, polling the status of the 
+        databases, and reports the result of each database. The command 
+        fails when a database is not closed within the time limit of the 
+        --wait-timeout option.
+
+    --wait-timeout DURATION
+        Specifies the time limit of the --wait option (e.g. 10m or 600 for 
+        10 minutes). The default is 5m.
+
+    --match REGEXP
+        Selects only the databases whose file names (with or without the 
+        extension) match the regular expression REGEXP.
+
+    --exclude PATTERN
+        Does not select the databases whose file names match the glob 
+        PATTERN (e.g. "Test*").
 `
 
 var deleteHelpTextTemplate = `Usage: fmcsadmin DELETE [TYPE] [SCHEDULE_NUMBER]
@@ -5379,6 +6771,20 @@ Options:
     -m message, --message message   
         Specifies a text message to be sent to the client that is being 
         disconnected.
+
+    --where CONDITIONS
+        Disconnects the clients that meet all the CONDITIONS joined by "and"
+        instead of CLIENT_NUMBER. The matching clients are listed before the
+        confirmation. Each condition is FIELD OPERATOR VALUE:
+          user, account, privset (Privilege Set), computer, app (App 
+          Version), database
+                      = or != with a name or a glob pattern (e.g. 'Guest*')
+          type        = or != with pro, go (FileMaker Go), webdirect or other
+          ip          =, !=, in or not in with addresses or networks 
+                      separated by commas (e.g. 10.2.0.0/16)
+          duration    >, >=, < or <= with a duration (e.g. 8h or 90m)
+        For example
+          fmcsadmin disconnect client --where 'ip in 10.2.0.0/16 and duration > 8h'
 `
 
 var enableHelpTextTemplate = `Usage: fmcsadmin ENABLE [TYPE] [SCHEDULE_NUMBER]
@@ -5440,6 +6846,11 @@ Description:
 
     Note: Input configuration names are not case sensitive.
 
+    Use "-o json" to output the schedules or the configurations as a JSON
+    document. Use "--format TEMPLATE" to format each schedule or the whole 
+    configuration document using the Go template. For example:
+      fmcsadmin GET SERVERCONFIG --format '{{.CacheSize.Value}}'
+
     Examples:
       fmcsadmin GET BACKUPTIME
       fmcsadmin GET BACKUPTIME 2
@@ -5464,6 +6875,45 @@ Description:
 Options:
     -s, --stats
         Reports additional details for each item.
+
+    -o FORMAT, --output FORMAT
+        Specifies the output format ("table", "json", "csv" or "tsv"). The 
+        JSON document includes all details of each item regardless of the -s
+        option. The CSV and TSV formats contain the same columns as the table.
+
+    --no-headers
+        Omits the header row of the table, CSV or TSV output.
+
+    --format TEMPLATE
+        Formats each item using the Go template. Fields are the same as the 
+        JSON document (e.g. ID, Filename, Folder, Status, Size and GuestFiles).
+        For example:
+             fmcsadmin LIST FILES -s --format '{{.ID}}\t{{.Filename}}\t{{.Size}}'
+`
+
+var loginHelpTextTemplate = `Usage: fmcsadmin LOGIN [options]
+
+Description:
+    Logs in to the server and stores the access token in the cache file of 
+    the user (e.g. ~/.cache/fmcsadmin/sessions.json), readable only by the 
+    user. Subsequent commands for the same server reuse the session instead 
+    of logging in and out each time, until the token expires or the LOGOUT 
+    command is run. A command given the credentials of another account 
+    logs in as that account instead. A session kept by a previous LOGIN 
+    command is closed.
+
+Options:
+    No command specific options.
+`
+
+var logoutHelpTextTemplate = `Usage: fmcsadmin LOGOUT
+
+Description:
+    Logs out of the session kept by the LOGIN command and removes the 
+    access token from the cache file.
+
+Options:
+    No command specific options.
 `
 
 var openHelpTextTemplate = `Usage: fmcsadmin OPEN [options] [FILE...] [PATH...]
@@ -5477,15 +6927,53 @@ Description:
     To specify a database by its ID rather than its filename, first use the 
     LIST FILES -s command to get a list of databases and their IDs.
 
+    FILE can also be a glob pattern of file names such as "Invoices_*". 
+    Quote the pattern to prevent the shell from expanding it. A FILE that 
+    is the name of a database (e.g. "Sales [2024]") is not a pattern.
+
 Options:
     --key encryptpass
         Specifies the encryption password for database(s) being opened.
 
+    --key-file-for-db FILE
+        Reads the encryption password from the first line of FILE ("-" for 
+        the standard input) instead of the --key option.
+
+    --keymap KEYMAPFILE
+        Specifies the JSON file that maps the file names or the IDs of 
+        databases to their encryption passwords, e.g.
+          {"Invoices.fmp12": {"key": "secret1", "saveKey": true},
+           "Contacts": "secret2", "5": "secret3"}
+        Encrypted databases not in KEYMAPFILE are skipped and listed.
+
+    --wait
+        Waits until each database is opened, polling the status of the 
+        databases, and reports the result of each database. The command 
+        fails when a database is not opened within the time limit of the 
+        --wait-timeout option.
+
+    --wait-timeout DURATION
+        Specifies the time limit of the --wait option (e.g. 10m or 600 for 
+        10 minutes). The default is 5m.
+
+    --match REGEXP
+        Selects only the databases whose file names (with or without the 
+        extension) match the regular expression REGEXP.
+
+    --exclude PATTERN
+        Does not select the databases whose file names match the glob 
+        PATTERN (e.g. "Test*").
+
     --savekey
         Saves the encryption password provided with the --key option. The
         password is saved on the server for each encrypted database being
         opened. The saved password allows the server to open an encrypted
         database without specifying the --key option every time.
+
+    The encrypted databases that fail to open are listed with their 
+    encryption password hints. When the command is run on a terminal 
+    without the --yes option, the encryption password of each of them is 
+    prompted for (press Enter to skip the database).
 `
 
 var pauseHelpTextTemplate = `Usage: fmcsadmin PAUSE [FILE...] [PATH...]
@@ -5498,8 +6986,53 @@ Description:
     After a database is paused, it is safe to copy or back up the database 
     until a RESUME command is performed.
 
+    FILE can also be a glob pattern of file names such as "Invoices_*". 
+    Quote the pattern to prevent the shell from expanding it. A FILE that 
+    is the name of a database (e.g. "Sales [2024]") is not a pattern.
+
 Options: 
-    No command specific options.
+    --wait
+        Waits until each database is paused, polling the status of the 
+        databases, and reports the result of each database. The command 
+        fails when a database is not paused within the time limit of the 
+        --wait-timeout option.
+
+    --wait-timeout DURATION
+        Specifies the time limit of the --wait option (e.g. 10m or 600 for 
+        10 minutes). The default is 5m.
+
+    --match REGEXP
+        Selects only the databases whose file names (with or without the 
+        extension) match the regular expression REGEXP.
+
+    --exclude PATTERN
+        Does not select the databases whose file names match the glob 
+        PATTERN (e.g. "Test*").
+`
+
+var pkiHelpTextTemplate = `Usage: fmcsadmin PKI [PKI_OP] [options] [NAME]
+
+Description:
+    This command lets the administrator set up PKI Authentication.
+
+    Valid PKI operations (PKI_OP) are:
+        KEYGEN     Generate an RSA private key and the public key to be 
+                   registered in Admin Console.
+        TEST       Log in to the server with the private key specified by 
+                   -i or --signer-command to check that the public key is 
+                   registered.
+
+    For the KEYGEN operation, the NAME of the public key in Admin Console 
+    is needed. The private key is written to NAME.pem and the public key to 
+    NAME.pub with spaces in NAME replaced by "_", so that the name derived 
+    from the file name of the private key matches NAME. For example
+      fmcsadmin pki keygen "Admin Key" --keyfilepass secret
+      fmcsadmin --fqdn fms.example.com -i Admin_Key.pem pki test
+
+Options:
+    --keyfilepass secret
+        Specifies the passphrase used to encrypt the private key file. The 
+        private key file is not encrypted by default.
 `
 
 var removeHelpTextTemplate = `Usage: fmcsadmin REMOVE [FILE...] [PATH...]
@@ -5510,8 +7043,20 @@ Description:
     databases in each folder (PATH) are removed. If no FILE or PATH is 
     specified, all closed databases in the hosting area are removed.
 
+    FILE can also be a glob pattern of file names such as "Invoices_*". 
+    Quote the pattern to prevent the shell from expanding it. A FILE that 
+    is the name of a database (e.g. "Sales [2024]") is not a pattern.
+    The databases selected by patterns, --match or --exclude are listed 
+    before the confirmation.
+
 Options:
-    No command specific options.
+    --match REGEXP
+        Selects only the databases whose file names (with or without the 
+        extension) match the regular expression REGEXP.
+
+    --exclude PATTERN
+        Does not select the databases whose file names match the glob 
+        PATTERN (e.g. "Test*").
 `
 
 var restartHelpTextTemplate = `Usage: fmcsadmin RESTART [TYPE]
@@ -5536,8 +7081,28 @@ Description:
     specified folders (PATH). If no FILE or PATH is specified, all paused 
     databases are resumed.
 
+    FILE can also be a glob pattern of file names such as "Invoices_*". 
+    Quote the pattern to prevent the shell from expanding it. A FILE that 
+    is the name of a database (e.g. "Sales [2024]") is not a pattern.
+
 Options:
-    No command specific options.
+    --wait
+        Waits until each database is resumed, polling the status of the 
+        databases, and reports the result of each database. The command 
+        fails when a database is not resumed within the time limit of the 
+        --wait-timeout option.
+
+    --wait-timeout DURATION
+        Specifies the time limit of the --wait option (e.g. 10m or 600 for 
+        10 minutes). The default is 5m.
+
+    --match REGEXP
+        Selects only the databases whose file names (with or without the 
+        extension) match the regular expression REGEXP.
+
+    --exclude PATTERN
+        Does not select the databases whose file names match the glob 
+        PATTERN (e.g. "Test*").
 `
 
 var runHelpTextTemplate = `Usage: fmcsadmin RUN SCHEDULE [SCHEDULE_NUMBER]
@@ -5563,12 +7128,23 @@ Description:
     For example: 
         fmcsadmin SEND -c 2 -m "This is a test message"
 
+    The result code of sending to each client is reported. Clients that are 
+    already being disconnected are not sent the message. 
+
 Options:
     -m message, --message message
         Specifies the text message to send.
 
     -c, --client
         Specifies a CLIENT_NUMBER.
+
+    --where CONDITIONS
+        Sends the message only to the clients that meet all the CONDITIONS. 
+        CONDITIONS are the same as those of the DISCONNECT CLIENT command, e.g.
+          fmcsadmin SEND -m "Please log out" --where "account = Sales"
+          fmcsadmin SEND -m "Please log out" --where "privset = '[Data Entry Only]'"
+          fmcsadmin SEND -m "Please update" --where "type = go"
+          fmcsadmin SEND -m "Please save" --where "type = webdirect" Invoices
 `
 
 var setHelpTextTemplate = `Usage: fmcsadmin SET [CONFIG_TYPE] [NAME1=VALUE1 NAME2=VALUE2 ...]
@@ -5633,9 +7209,16 @@ Description:
         CLIENT          Retrieves the status of a client specified by 
                         CLIENT_NUMBER.
         FILE            Retrieves the status of database(s) specified by FILE.
+                        FILE can also be a glob pattern such as "Invoices_*".
 
-Options:
-    No command specific options.
+Options: (applicable to FILE only)
+    --match REGEXP
+        Selects only the databases whose file names (with or without the 
+        extension) match the regular expression REGEXP.
+
+    --exclude PATTERN
+        Does not select the databases whose file names match the glob 
+        PATTERN (e.g. "Test*").
 `
 
 var stopHelpTextTemplate = `Usage: fmcsadmin STOP [TYPE] [options]
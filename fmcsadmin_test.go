@@ -2,17 +2,26 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/emic/fmcsadmin/fmsadmin"
+	"github.com/emic/fmcsadmin/pki"
+	"github.com/emic/fmcsadmin/session"
+	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -231,6 +240,127 @@ func TestRunWithIdentityFileOption(t *testing.T) {
 	assert.Equal(t, 20405, status)
 }
 
+func TestGetJWTToken(t *testing.T) {
+	for _, name := range []string{"rsa_pkcs1.pem", "rsa_pkcs8.pem"} {
+		tokenString, exitStatus, err := getJWTToken(params{identityFile: filepath.Join("pki", "testdata", name), tokenLifetime: 15 * time.Minute})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, exitStatus)
+
+		claims := jwt.MapClaims{}
+		_, _, err = jwt.NewParser().ParseUnverified(tokenString, claims)
+		assert.Nil(t, err)
+		assert.Equal(t, strings.Replace(strings.TrimSuffix(name, ".pem"), "_", " ", -1), claims["iss"])
+		assert.Equal(t, "fmsadminapi", claims["aud"])
+	}
+
+	tokenString, exitStatus, err := getJWTToken(params{identityFile: filepath.Join("pki", "testdata", "rsa_pkcs1.pem"), keyName: "Admin Key", tokenLifetime: 5 * time.Minute})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, exitStatus)
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(tokenString, claims)
+	assert.Nil(t, err)
+	assert.Equal(t, "Admin Key", claims["iss"])
+	exp, err := claims.GetExpirationTime()
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), exp.Time, 5*time.Second)
+
+	if runtime.GOOS != "windows" {
+		// external signer
+		tokenString, exitStatus, err = getJWTToken(params{signerCommand: "printf c2lnbmF0dXJl", keyName: "Admin Key", tokenLifetime: 5 * time.Minute})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, exitStatus)
+		assert.True(t, strings.HasSuffix(tokenString, ".c2lnbmF0dXJl"))
+
+		_, exitStatus, err = getJWTToken(params{signerCommand: "exit 1", keyName: "Admin Key", tokenLifetime: 5 * time.Minute})
+		assert.NotNil(t, err)
+		assert.Equal(t, 9, exitStatus)
+	}
+
+	// EC keys are not supported
+	_, exitStatus, _ = getJWTToken(params{identityFile: filepath.Join("pki", "testdata", "ec_pkcs8.pem"), tokenLifetime: 15 * time.Minute})
+	assert.Equal(t, 21, exitStatus)
+
+	_, keyType, exitStatus := detectPrivateKeyFormat(filepath.Join("pki", "testdata", "rsa_pkcs8_aes256.pem"), "")
+	assert.Equal(t, "ENCRYPTED PRIVATE KEY", keyType)
+	assert.Equal(t, 212, exitStatus)
+	_, _, exitStatus = detectPrivateKeyFormat(filepath.Join("pki", "testdata", "rsa_pkcs8_aes256.pem"), "passphrase")
+	assert.Equal(t, 0, exitStatus)
+	_, _, exitStatus = detectPrivateKeyFormat(filepath.Join("pki", "testdata", "notexist.pem"), "")
+	assert.Equal(t, 20405, exitStatus)
+}
+
+func TestParseDuration(t *testing.T) {
+	lifetime, err := parseDuration("5m")
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, lifetime)
+
+	lifetime, err = parseDuration("300")
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, lifetime)
+
+	for _, s := range []string{"0", "-1m", "5x", ""} {
+		_, err = parseDuration(s)
+		assert.NotNil(t, err)
+	}
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run(strings.Split("fmcsadmin --fqdn example.jp --token-lifetime 0 list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid parameter for option: --token-lifetime")
+
+	outStream.Reset()
+	status = cli.Run([]string{"fmcsadmin", "--fqdn", "example.jp", "--signer-command", "hsm-sign", "list", "files"})
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "--signer-command requires --key-name")
+}
+
+func TestReadSecretOptions(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	assert.Nil(t, os.WriteFile(passwordFile, []byte("PASSWORD\r\nsecond line\n"), 0600))
+	keyFile := filepath.Join(dir, "key")
+	assert.Nil(t, os.WriteFile(keyFile, []byte("KEY\n"), 0600))
+	emptyFile := filepath.Join(dir, "empty")
+	assert.Nil(t, os.WriteFile(emptyFile, []byte("\n"), 0600))
+
+	cFlags := commandOptions{passwordFile: passwordFile, keyFileForDB: keyFile, keyFilePassFile: keyFile}
+	assert.Nil(t, readSecretOptions(&cFlags))
+	assert.Equal(t, "PASSWORD", cFlags.password)
+	assert.Equal(t, "KEY", cFlags.key)
+	assert.Equal(t, "KEY", cFlags.keyFilePass)
+
+	// the standard input
+	r, w, err := os.Pipe()
+	assert.Nil(t, err)
+	_, _ = w.WriteString("STDIN\n")
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	cFlags = commandOptions{passwordStdin: true}
+	assert.Nil(t, readSecretOptions(&cFlags))
+	assert.Equal(t, "STDIN", cFlags.password)
+
+	cFlags = commandOptions{passwordStdin: true, keyFileForDB: "-"}
+	assert.EqualError(t, readSecretOptions(&cFlags), "--password-stdin and --key-file-for-db cannot both read the standard input")
+
+	cFlags = commandOptions{passwordStdin: true, passwordFile: passwordFile}
+	assert.EqualError(t, readSecretOptions(&cFlags), "--password-file and --password-stdin cannot be used together")
+
+	cFlags = commandOptions{password: "PASSWORD", passwordFile: passwordFile}
+	assert.EqualError(t, readSecretOptions(&cFlags), "-p and --password-file cannot be used together")
+
+	cFlags = commandOptions{keyFileForDB: emptyFile}
+	assert.EqualError(t, readSecretOptions(&cFlags), "--key-file-for-db: the secret is empty")
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run([]string{"fmcsadmin", "--password-file", filepath.Join(dir, "notexist"), "list", "files"})
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "--password-file: ")
+}
+
 func TestRunWithVersionOption1(t *testing.T) {
 	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
 	cli := &cli{outStream: outStream, errStream: errStream}
@@ -338,6 +468,23 @@ func TestRunDisconnectCommand1(t *testing.T) {
 	assert.Contains(t, outStream.String(), expected)
 }
 
+func TestRunDisconnectCommandWithEmptyWhere(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+
+	for _, args := range [][]string{
+		{"fmcsadmin", "disconnect", "client", "--where", ""},
+		{"fmcsadmin", "disconnect", "client", "--where", " "},
+		{"fmcsadmin", "send", "-m", "Hello", "--where", ""},
+		{"fmcsadmin", "send", "-m", "Hello", "--where", " "},
+	} {
+		outStream.Reset()
+		status := cli.Run(args)
+		assert.Equal(t, 10001, status)
+		assert.Contains(t, outStream.String(), "Invalid parameter for option: --where (an expression is required)")
+	}
+}
+
 func TestRunableEnbleCommand1(t *testing.T) {
 	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
 	cli := &cli{outStream: outStream, errStream: errStream}
@@ -507,6 +654,39 @@ func TestRunShowListCommandHelp(t *testing.T) {
 	assert.Contains(t, outStream.String(), expected)
 }
 
+func TestRunShowLoginCommandHelp(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+
+	args := strings.Split("fmcsadmin help login", " ")
+	status := cli.Run(args)
+	assert.Equal(t, 0, status)
+	expected := "Usage: fmcsadmin LOGIN [options]"
+	assert.Contains(t, outStream.String(), expected)
+}
+
+func TestRunShowPkiCommandHelp(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+
+	args := strings.Split("fmcsadmin help pki", " ")
+	status := cli.Run(args)
+	assert.Equal(t, 0, status)
+	expected := "Usage: fmcsadmin PKI [PKI_OP] [options] [NAME]"
+	assert.Contains(t, outStream.String(), expected)
+}
+
+func TestRunShowLogoutCommandHelp(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+
+	args := strings.Split("fmcsadmin help logout", " ")
+	status := cli.Run(args)
+	assert.Equal(t, 0, status)
+	expected := "Usage: fmcsadmin LOGOUT"
+	assert.Contains(t, outStream.String(), expected)
+}
+
 func TestRunShowOpenCommandHelp(t *testing.T) {
 	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
 	cli := &cli{outStream: outStream, errStream: errStream}
@@ -656,14 +836,16 @@ func TestRunOpenCommand1(t *testing.T) {
 	}
 
 	if running == false {
+		status := "CLOSED"
 		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\", \"totalDBCount\": 1, \"clients\": [], \"databases\": [{\"id\": \"1\", \"filename\": \"TestDB.fmp12\", \"status\": \"CLOSED\", \"folder\": \"filemac:/Macintosh HD/Library/FileMaker Server/Data/Databases/Sample/\", \"decryptHint\": \"\"}]}, \"messages\": [{\"code\": \"0\"}]}")
 			if strings.Contains(r.URL.Path, "/fmi/admin/api/v2/databases/") {
 				request, _ := io.ReadAll(r.Body)
 				if strings.Contains(string([]byte(request)), "\"status\":\"OPENED\"") {
 					assert.Equal(t, "{\"status\":\"OPENED\",\"key\":\"\",\"saveKey\":false}", string([]byte(request)))
+					status = "NORMAL"
 				}
 			}
+			fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\", \"totalDBCount\": 1, \"clients\": [], \"databases\": [{\"id\": \"1\", \"filename\": \"TestDB.fmp12\", \"status\": \""+status+"\", \"folder\": \"filemac:/Macintosh HD/Library/FileMaker Server/Data/Databases/Sample/\", \"decryptHint\": \"\"}]}, \"messages\": [{\"code\": \"0\"}]}")
 		})
 
 		address := "127.0.0.1:16001"
@@ -698,6 +880,358 @@ func TestRunStatusCommand1(t *testing.T) {
 	assert.Contains(t, outStream.String(), expected)
 }
 
+func TestRunListFilesCommandWithJSONOutput(t *testing.T) {
+	running := true
+	url := "http://127.0.0.1:16001/fmi/admin/api/v2/user/auth"
+	_, err := http.Get(url)
+	if err != nil {
+		running = false
+	}
+
+	if running == false {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\", \"totalDBCount\": 1, \"clients\": [], \"databases\": [{\"id\": \"1\", \"filename\": \"TestDB.fmp12\", \"status\": \"NORMAL\", \"folder\": \"filemac:/Macintosh HD/Library/FileMaker Server/Data/Databases/Sample/\", \"decryptHint\": \"HINT\"}]}, \"messages\": [{\"code\": \"0\"}]}")
+		})
+
+		address := "127.0.0.1:16001"
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ts := httptest.Server{
+			Listener: l,
+			Config:   &http.Server{Handler: handler},
+		}
+		ts.Start()
+		defer ts.Close()
+	}
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	args := strings.Split("fmcsadmin list files -s -o json -u USERNAME -p PASSWORD", " ")
+	status := cli.Run(args)
+	assert.Equal(t, 0, status)
+	var result struct {
+		Files []fileInfo `json:"files"`
+	}
+	assert.Nil(t, json.Unmarshal(outStream.Bytes(), &result))
+	assert.Equal(t, 1, len(result.Files))
+	assert.Equal(t, "TestDB.fmp12", result.Files[0].Filename)
+	assert.Equal(t, "filemac:/Macintosh HD/Library/FileMaker Server/Data/Databases/Sample/", result.Files[0].Folder)
+	assert.Equal(t, "HINT", result.Files[0].DecryptHint)
+
+	outStream.Reset()
+	args = strings.Split("fmcsadmin list files -o xml -u USERNAME -p PASSWORD", " ")
+	status = cli.Run(args)
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid parameter for option: --output")
+}
+
+func TestRunWithProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("FMCSADMIN_PROFILE", "")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "fmcsadmin"), 0700))
+	config := "profiles:\n  local:\n    username: USERNAME\n    tls:\n      cacert: " + filepath.Join(dir, "notexist.pem") + "\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "fmcsadmin", "config.yaml"), []byte(config), 0600))
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run(strings.Split("fmcsadmin --profile notexist list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid profile")
+
+	outStream.Reset()
+	t.Setenv("FMCSADMIN_PROFILE", "local")
+	status = cli.Run(strings.Split("fmcsadmin list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid TLS option")
+}
+
+func TestLoginWithCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell script")
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username == "USERNAME" && password == "PASSWORD" {
+			fmt.Fprintln(w, `{"response": {"token": "ACCESSTOKEN"}, "messages": [{"code": "0", "text": "OK"}]}`)
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "212", "text": "Invalid user account and/or password; please try again"}]}`)
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	script := filepath.Join(dir, "fmcsadmin-credential-test")
+	data := "#!/bin/sh\necho \"$1\" >> " + log + "\nif [ \"$1\" = get ]; then\n  echo username=USERNAME\n  echo password=$(cat " + filepath.Join(dir, "password") + ")\nfi\n"
+	assert.Nil(t, os.WriteFile(script, []byte(data), 0700))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "password"), []byte("PASSWORD"), 0600))
+	client := fmsadmin.NewClient(ts.URL)
+	token, exitStatus, err := login(client, "", "", params{credentialHelper: script})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, "ACCESSTOKEN", token)
+
+	// the rejected credential is erased
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "password"), []byte("WRONG"), 0600))
+	client = fmsadmin.NewClient(ts.URL)
+	token, exitStatus, err = login(client, "", "", params{credentialHelper: script})
+	assert.Nil(t, err)
+	assert.Equal(t, 9, exitStatus)
+	assert.Equal(t, "", token)
+
+	out, err := os.ReadFile(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "get\nget\nerase\n", string(out))
+}
+
+func TestRunLoginAndLogoutCommand(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:16001/fmi/admin/api/v2/user/auth")
+	if err == nil {
+		t.Skip("a server is running")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+
+	requests := []string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\", \"status\": \"RUNNING\", \"totalDBCount\": 0, \"databases\": []}, \"messages\": [{\"code\": \"0\"}]}")
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:16001")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run(strings.Split("fmcsadmin login -u USERNAME -p PASSWORD", " "))
+	assert.Equal(t, 0, status)
+	assert.Equal(t, []string{"POST /fmi/admin/api/v2/user/auth"}, requests)
+
+	// the cached token is reused without creating a new session
+	requests = []string{}
+	status = cli.Run(strings.Split("fmcsadmin list files", " "))
+	assert.Equal(t, 0, status)
+	assert.Equal(t, []string{"GET /fmi/admin/api/v2/server/status", "GET /fmi/admin/api/v2/databases"}, requests)
+
+	requests = []string{}
+	status = cli.Run(strings.Split("fmcsadmin -u USERNAME list files", " "))
+	assert.Equal(t, 0, status)
+	assert.Equal(t, []string{"GET /fmi/admin/api/v2/server/status", "GET /fmi/admin/api/v2/databases"}, requests)
+
+	// the cached token is not used for another account
+	requests = []string{}
+	status = cli.Run(strings.Split("fmcsadmin -u OTHER -p PASSWORD list files", " "))
+	assert.Equal(t, 0, status)
+	assert.Equal(t, []string{"POST /fmi/admin/api/v2/user/auth", "GET /fmi/admin/api/v2/databases"}, requests)
+
+	requests = []string{}
+	status = cli.Run(strings.Split("fmcsadmin logout", " "))
+	assert.Equal(t, 0, status)
+	assert.Equal(t, []string{"DELETE /fmi/admin/api/v2/user/auth/ACCESSTOKEN"}, requests)
+
+	path, _ := session.DefaultPath()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunPkiKeygenCommand(t *testing.T) {
+	dir := t.TempDir()
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+
+	status := cli.Run([]string{"fmcsadmin", "pki", "keygen", filepath.Join(dir, "Admin Key")})
+	assert.Equal(t, 0, status)
+	assert.Contains(t, outStream.String(), "with the name \"Admin Key\"")
+	assert.Contains(t, outStream.String(), "-----BEGIN PUBLIC KEY-----")
+
+	keyFile := filepath.Join(dir, "Admin_Key.pem")
+	tokenString, exitStatus, err := getJWTToken(params{identityFile: keyFile, tokenLifetime: 15 * time.Minute})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, exitStatus)
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(tokenString, claims)
+	assert.Nil(t, err)
+	assert.Equal(t, "Admin Key", claims["iss"])
+	_, err = os.Stat(filepath.Join(dir, "Admin_Key.pub"))
+	assert.Nil(t, err)
+
+	// existing files are not overwritten
+	outStream.Reset()
+	status = cli.Run([]string{"fmcsadmin", "pki", "keygen", filepath.Join(dir, "Admin_Key")})
+	assert.Equal(t, 20406, status)
+
+	outStream.Reset()
+	status = cli.Run([]string{"fmcsadmin", "pki", "keygen", filepath.Join(dir, "Encrypted_Key"), "--keyfilepass", "passphrase"})
+	assert.Equal(t, 0, status)
+	data, err := os.ReadFile(filepath.Join(dir, "Encrypted_Key.pem"))
+	assert.Nil(t, err)
+	assert.True(t, pki.IsEncrypted(data))
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin pki keygen", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Key name is not specified.")
+}
+
+func TestRunPkiTestCommand(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:16001/fmi/admin/api/v2/user/auth")
+	if err == nil {
+		t.Skip("a server is running")
+	}
+
+	requests := []string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "PKI ") {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, "{\"response\": {}, \"messages\": [{\"code\": \"212\"}]}")
+			return
+		}
+		fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\"}, \"messages\": [{\"code\": \"0\"}]}")
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:16001")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run([]string{"fmcsadmin", "-i", filepath.Join("pki", "testdata", "rsa_pkcs1.pem"), "pki", "test"})
+	assert.Equal(t, 0, status)
+	assert.Contains(t, outStream.String(), "PKI Authentication succeeded with the key \"rsa pkcs1\".")
+	assert.Equal(t, []string{"POST /fmi/admin/api/v2/user/auth", "DELETE /fmi/admin/api/v2/user/auth/ACCESSTOKEN"}, requests)
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin pki test", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Private key is not specified.")
+}
+
+func TestRunWithTLSOptions(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run(strings.Split("fmcsadmin --fqdn example.jp --cacert "+filepath.Join(t.TempDir(), "notexist.pem")+" list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid TLS option")
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin --fqdn example.jp --cert client.pem list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid TLS option")
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin --url example.jp list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid parameter for option: --url")
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin --fqdn example.jp --insecure help", " "))
+	assert.Equal(t, 0, status)
+	assert.Contains(t, errStream.String(), "WARNING: TLS certificate verification is disabled")
+}
+
+func TestRunWithProxyOptions(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	status := cli.Run(strings.Split("fmcsadmin --fqdn example.jp --proxy ftp://proxy.example.jp list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid parameter for option: --proxy")
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin --fqdn example.jp --proxy http://proxy.example.jp:3128 --ssh-jump admin@bastion.example.jp list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "--proxy and --ssh-jump cannot be used together")
+
+	outStream.Reset()
+	status = cli.Run(strings.Split("fmcsadmin --fqdn example.jp --ssh-jump admin@ list files", " "))
+	assert.Equal(t, 10001, status)
+	assert.Contains(t, outStream.String(), "Invalid parameter for option: --ssh-jump")
+}
+
+func TestOutputTable(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{outStream: outStream, errStream: errStream}
+	header := []string{"Client ID", "User Name", "Computer Name", "Ext Privilege"}
+	data := [][]string{{"2", "Doe, John", "John's \"PC\"", "fmapp"}}
+
+	c.output = "csv"
+	outputTable(c, header, data)
+	assert.Equal(t, "Client ID,User Name,Computer Name,Ext Privilege\n2,\"Doe, John\",\"John's \"\"PC\"\"\",fmapp\n", outStream.String())
+
+	outStream.Reset()
+	c.output = "tsv"
+	c.noHeaders = true
+	outputTable(c, header, data)
+	assert.Equal(t, "2\tDoe, John\t\"John's \"\"PC\"\"\"\tfmapp\n", outStream.String())
+}
+
+func TestOutputItems(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{outStream: outStream, errStream: errStream}
+	files := []fileInfo{{ID: 1, Filename: "TestDB.fmp12", Size: 1048576}, {ID: 2, Filename: "Sample.fmp12", Size: 2048}}
+
+	c.format = `{{.ID}}\t{{.Filename}}\t{{.Size}}`
+	assert.Equal(t, 0, outputItems(c, "files", files))
+	assert.Equal(t, "1\tTestDB.fmp12\t1048576\n2\tSample.fmp12\t2048\n", outStream.String())
+
+	outStream.Reset()
+	c.format = "{{.Unknown}}"
+	assert.Equal(t, 10001, outputItems(c, "files", files))
+
+	outStream.Reset()
+	c.format = ""
+	c.output = "json"
+	assert.Equal(t, 0, outputItems(c, "files", []fileInfo{}))
+	assert.JSONEq(t, `{"files": []}`, outStream.String())
+
+	_, err := parseTemplate("{{.ID", "")
+	assert.NotNil(t, err)
+}
+
+func TestPrintSetting(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{outStream: outStream, errStream: errStream}
+	printSetting(c, "CacheSize", setting{Value: 512, Default: 512, Range: []int{64, 1048576}})
+	printSetting(c, "SecureFilesOnly", setting{Value: true, Default: true})
+	printSetting(c, "Encoding", setting{Value: "UTF-8", Options: []string{"UTF-8", "ISO-8859-1"}})
+	printSetting(c, "PreValidation", setting{})
+	assert.Equal(t, "CacheSize = 512 [default: 512, range: 64-1048576] \nSecureFilesOnly = true [default: true] \nEncoding = UTF-8 [ UTF-8 ISO-8859-1 ]\nPreValidation = \n", outStream.String())
+
+	outStream.Reset()
+	c.output = "json"
+	printSetting(c, "CacheSize", setting{Value: 512, Default: 512, Range: []int{64, 1048576}})
+	assert.Equal(t, "", outStream.String())
+	outputJSON(c, c.settings)
+	assert.JSONEq(t, `{"CacheSize": {"value": 512, "default": 512, "range": [64, 1048576]}}`, outStream.String())
+
+	outStream.Reset()
+	c.format = "{{.CacheSize.Value}} {{.CacheSize.Default}}"
+	assert.Equal(t, 0, outputTemplate(c, "{{template \"format\" .}}\n", c.settings))
+	assert.Equal(t, "512 512\n", outStream.String())
+}
+
 func TestGetFlags(t *testing.T) {
 	var expected []string
 	var args []string
@@ -716,6 +1250,8 @@ func TestGetFlags(t *testing.T) {
 	flags.message = ""
 	flags.clientID = -1
 	flags.graceTime = 90
+	flags.timeout = -1
+	flags.retries = -1
 
 	/*
 	 * cancel
@@ -1333,6 +1869,153 @@ func TestGetFlags(t *testing.T) {
 	assert.Equal(t, true, resultFlags.statsFlag)
 	assert.Equal(t, expected, cmdArgs)
 
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin -o json list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "json", resultFlags.output)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin list files -s --output json", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, true, resultFlags.statsFlag)
+	assert.Equal(t, "json", resultFlags.output)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "clients"}
+	args = strings.Split("fmcsadmin list clients -o csv --no-headers", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "csv", resultFlags.output)
+	assert.Equal(t, true, resultFlags.noHeadersFlag)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = []string{"fmcsadmin", "list", "files", "-s", "--format", "{{.ID}} {{.Filename}}"}
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "{{.ID}} {{.Filename}}", resultFlags.format)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --profile prod-tokyo list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "prod-tokyo", resultFlags.profile)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = []string{"fmcsadmin", "--credential-helper", "pass show fms/prod", "list", "files"}
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "pass show fms/prod", resultFlags.credentialHelper)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --timeout 60 --retries 3 list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, 60, resultFlags.timeout)
+	assert.Equal(t, 3, resultFlags.retries)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --fqdn example.jp --cacert ca.pem --cert client.pem --cert-key client.key --pin-sha256 sha256//HASH --insecure list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "ca.pem", resultFlags.caCert)
+	assert.Equal(t, "client.pem", resultFlags.cert)
+	assert.Equal(t, "client.key", resultFlags.certKey)
+	assert.Equal(t, "sha256//HASH", resultFlags.pinSHA256)
+	assert.Equal(t, true, resultFlags.insecureFlag)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --url https://proxy.example.jp:8443/fms-admin list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "https://proxy.example.jp:8443/fms-admin", resultFlags.serverURL)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --fqdn fms.example.jp --proxy socks5://127.0.0.1:1080 list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "socks5://127.0.0.1:1080", resultFlags.proxy)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --fqdn fms.example.jp --ssh-jump admin@bastion.example.jp list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "admin@bastion.example.jp", resultFlags.sshJump)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --fqdn fms.example.jp -i /path/to/key.pem --key-name Admin --token-lifetime 5m list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "Admin", resultFlags.keyName)
+	assert.Equal(t, "5m", resultFlags.tokenLifetime)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"list", "files"}
+	args = strings.Split("fmcsadmin --fqdn fms.example.jp --key-name Admin --signer-command hsm-sign list files", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "hsm-sign", resultFlags.signerCommand)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"open", "TestDB"}
+	args = strings.Split("fmcsadmin -u admin --password-file /path/to/password open --key-file-for-db - TestDB", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "/path/to/password", resultFlags.passwordFile)
+	assert.Equal(t, "-", resultFlags.keyFileForDB)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"certificate", "import", "Signed.cer"}
+	args = strings.Split("fmcsadmin --password-stdin certificate import --keyfilepass-file /path/to/keyfilepass Signed.cer", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.True(t, resultFlags.passwordStdin)
+	assert.Equal(t, "/path/to/keyfilepass", resultFlags.keyFilePassFile)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"close", "Invoices"}
+	args = strings.Split("fmcsadmin close --wait --wait-timeout 10m Invoices", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.True(t, resultFlags.waitFlag)
+	assert.Equal(t, "10m", resultFlags.waitTimeout)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"pause"}
+	args = strings.Split("fmcsadmin pause --match ^Archive_[0-9]{4}$ --exclude Test*", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "^Archive_[0-9]{4}$", resultFlags.match)
+	assert.Equal(t, "Test*", resultFlags.exclude)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"remove", "Test*"}
+	args = strings.Split("fmcsadmin --dry-run remove Test*", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.True(t, resultFlags.dryRunFlag)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"close", "Invoices"}
+	args = strings.Split("fmcsadmin close --drain --drain-timeout 15m --drain-reminder 3m Invoices", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.True(t, resultFlags.drainFlag)
+	assert.Equal(t, "15m", resultFlags.drainTimeout)
+	assert.Equal(t, "3m", resultFlags.drainReminder)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"disconnect", "client"}
+	args = []string{"fmcsadmin", "disconnect", "client", "--where", "ip in 10.2.0.0/16 and duration > 8h"}
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "ip in 10.2.0.0/16 and duration > 8h", resultFlags.where)
+	assert.True(t, resultFlags.whereFlag)
+	assert.Equal(t, expected, cmdArgs)
+
+	args = []string{"fmcsadmin", "disconnect", "client", "--where", ""}
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "", resultFlags.where)
+	assert.True(t, resultFlags.whereFlag)
+	assert.Equal(t, expected, cmdArgs)
+
+	expected = []string{"open", "Invoices"}
+	args = strings.Split("fmcsadmin open --keymap keys.json Invoices", " ")
+	cmdArgs, resultFlags, _ = getFlags(args, flags)
+	assert.Equal(t, "keys.json", resultFlags.keyMap)
+	assert.Equal(t, expected, cmdArgs)
+
 	// list plugins
 	expected = []string{"list", "plugins"}
 	args = strings.Split("fmcsadmin list plugins", " ")
@@ -1691,6 +2374,25 @@ func TestGetBaseURI(t *testing.T) {
 	assert.Equal(t, "https://example.jp", getBaseURI(" example.jp"))
 }
 
+func TestParseServerURL(t *testing.T) {
+	baseURI, err := parseServerURL("https://proxy.example.jp:8443/fms-admin/")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://proxy.example.jp:8443/fms-admin", baseURI)
+	baseURI, err = parseServerURL("http://127.0.0.1:16002")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://127.0.0.1:16002", baseURI)
+	baseURI, err = parseServerURL("https://example.jp/fmi/admin/api/v2")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.jp", baseURI)
+
+	_, err = parseServerURL("example.jp")
+	assert.NotNil(t, err)
+	_, err = parseServerURL("ftp://example.jp")
+	assert.NotNil(t, err)
+	_, err = parseServerURL("https://example.jp/?key=value")
+	assert.NotNil(t, err)
+}
+
 func TestGetAPIBasePath(t *testing.T) {
 	assert.Equal(t, "/fmi/admin/api/v2", getAPIBasePath())
 }
@@ -1702,6 +2404,598 @@ func TestGetServerVersionAsFloat(t *testing.T) {
 	assert.Equal(t, 19.3, version)
 }
 
+func TestLoadKeyMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data := `{"Invoices.fmp12": {"key": "secret1", "saveKey": true}, "Contacts": "secret2", "5": "secret3"}`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+
+	keyMap, err := loadKeyMap(path)
+	assert.Nil(t, err)
+
+	entry, ok := lookupKeyMap(keyMap, 1, "Invoices.fmp12")
+	assert.True(t, ok)
+	assert.Equal(t, keyMapEntry{Key: "secret1", SaveKey: true}, entry)
+
+	entry, ok = lookupKeyMap(keyMap, 2, "Contacts.fmp12")
+	assert.True(t, ok)
+	assert.Equal(t, keyMapEntry{Key: "secret2"}, entry)
+
+	entry, ok = lookupKeyMap(keyMap, 5, "Products.fmp12")
+	assert.True(t, ok)
+	assert.Equal(t, keyMapEntry{Key: "secret3"}, entry)
+
+	_, ok = lookupKeyMap(keyMap, 6, "Sales.fmp12")
+	assert.False(t, ok)
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"Invoices.fmp12": 1}`), 0600))
+	_, err = loadKeyMap(path)
+	assert.NotNil(t, err)
+}
+
+func TestRunOpenCommandWithKeyMap(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:16001/fmi/admin/api/v2/user/auth")
+	if err == nil {
+		t.Skip("a server is running")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+
+	status := map[string]string{"1": "CLOSED", "2": "CLOSED", "3": "CLOSED"}
+	requests := []string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PATCH":
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+			status[filepath.Base(r.URL.Path)] = "NORMAL"
+			fmt.Fprintln(w, "{\"response\": {}, \"messages\": [{\"code\": \"0\"}]}")
+		case strings.HasSuffix(r.URL.Path, "/databases"):
+			fmt.Fprintf(w, "{\"response\": {\"databases\": ["+
+				"{\"id\": \"1\", \"filename\": \"Invoices.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \"%s\", \"isEncrypted\": true},"+
+				"{\"id\": \"2\", \"filename\": \"Contacts.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \"%s\", \"isEncrypted\": true},"+
+				"{\"id\": \"3\", \"filename\": \"Products.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \"%s\", \"isEncrypted\": false}"+
+				"]}, \"messages\": [{\"code\": \"0\"}]}\n", status["1"], status["2"], status["3"])
+		default:
+			fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\"}, \"messages\": [{\"code\": \"0\"}]}")
+		}
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:16001")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	path := filepath.Join(dir, "keys.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"Invoices": {"key": "secret1", "saveKey": true}}`), 0600))
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	exitStatus := cli.Run(strings.Split("fmcsadmin -u USERNAME -p PASSWORD open --keymap "+path, " "))
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, []string{
+		"PATCH /fmi/admin/api/v2/databases/1 {\"status\":\"OPENED\",\"key\":\"secret1\",\"saveKey\":true}",
+		"PATCH /fmi/admin/api/v2/databases/3 {\"status\":\"OPENED\",\"key\":\"\",\"saveKey\":false}",
+	}, requests)
+	assert.Contains(t, outStream.String(), "File Opened: Invoices.fmp12")
+	assert.Contains(t, outStream.String(), "File Opened: Products.fmp12")
+	assert.Contains(t, outStream.String(), "File Skipped (no encryption password in the key map file): Contacts.fmp12")
+
+	outStream.Reset()
+	exitStatus = cli.Run(strings.Split("fmcsadmin -u USERNAME -p PASSWORD open --key secret --keymap "+path, " "))
+	assert.Equal(t, 10001, exitStatus)
+	assert.Contains(t, outStream.String(), "--key and --keymap cannot be used together")
+}
+
+func TestRunCloseCommandWithDryRun(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:16001/fmi/admin/api/v2/user/auth")
+	if err == nil {
+		t.Skip("a server is running")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+
+	requests := []string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/databases"):
+			fmt.Fprintln(w, "{\"response\": {\"databases\": ["+
+				"{\"id\": \"1\", \"filename\": \"Invoices_2024.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \"NORMAL\"},"+
+				"{\"id\": \"2\", \"filename\": \"Contacts.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \"NORMAL\"}"+
+				"]}, \"messages\": [{\"code\": \"0\"}]}")
+		case strings.HasSuffix(r.URL.Path, "/clients"):
+			fmt.Fprintln(w, "{\"response\": {\"clients\": []}, \"messages\": [{\"code\": \"0\"}]}")
+		default:
+			fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\"}, \"messages\": [{\"code\": \"0\"}]}")
+		}
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:16001")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	exitStatus := cli.Run(strings.Split("fmcsadmin -u USERNAME -p PASSWORD --dry-run close Invoices_* --wait", " "))
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, "Matching database(s):\n"+
+		"    Invoices_2024.fmp12\n"+
+		"File Closing: Invoices_2024.fmp12\n"+
+		"PATCH /fmi/admin/api/v2/databases/1 {\"status\":\"CLOSED\",\"messageText\":\"\",\"force\":false}\n", outStream.String())
+	for _, request := range requests {
+		assert.NotContains(t, request, "PATCH")
+	}
+}
+
+func TestRunSetCommandWithDryRun(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:16001/fmi/admin/api/v2/user/auth")
+	if err == nil {
+		t.Skip("a server is running")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+
+	requests := []string{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/server/metadata"):
+			fmt.Fprintln(w, "{\"response\": {\"ServerVersion\": \"21.1.1.40\"}, \"messages\": [{\"code\": \"0\"}]}")
+		case strings.HasSuffix(r.URL.Path, "/server/config/general"):
+			fmt.Fprintln(w, "{\"response\": {\"cacheSize\": 512, \"maxFiles\": 256, \"maxProConnections\": 250, \"maxPSOS\": 100, \"onlyOpenLastOpenedDatabases\": false}, \"messages\": [{\"code\": \"0\"}]}")
+		case strings.HasSuffix(r.URL.Path, "/php/config"):
+			fmt.Fprintln(w, "{\"response\": {\"enabled\": false, \"characterEncoding\": \"UTF-8\", \"errorMessageLanguage\": \"en\", \"dataPreValidation\": false, \"useFileMakerPhp\": false}, \"messages\": [{\"code\": \"0\"}]}")
+		case strings.HasSuffix(r.URL.Path, "/xml/config"):
+			fmt.Fprintln(w, "{\"response\": {\"enabled\": false}, \"messages\": [{\"code\": \"0\"}]}")
+		default:
+			fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\"}, \"messages\": [{\"code\": \"0\"}]}")
+		}
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:16001")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	exitStatus := cli.Run(strings.Split("fmcsadmin -u USERNAME -p PASSWORD --dry-run set serverprefs cachesize=1024 requiresecuredb=false authenticatedstream=2 blocknewusersenabled=true", " "))
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/server/config/general {\"cacheSize\":1024,\"maxFiles\":256,\"maxProConnections\":250,\"maxPSOS\":100}\n"+
+		"PATCH /fmi/admin/api/v2/server/config/security {\"requireSecureDB\":false}\n"+
+		"PATCH /fmi/admin/api/v2/server/config/authenticatedstream {\"authenticatedStream\":2}\n"+
+		"PATCH /fmi/admin/api/v2/server/config/blocknewusers {\"blockNewUsers\":true}\n", outStream.String())
+
+	outStream.Reset()
+	exitStatus = cli.Run(strings.Split("fmcsadmin -u USERNAME -p PASSWORD --dry-run set cwpconfig enablephp=true encoding=ISO-8859-1 enablexml=true", " "))
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/php/config {\"enabled\":true,\"characterEncoding\":\"ISO-8859-1\",\"errorMessageLanguage\":\"en\",\"dataPreValidation\":false,\"useFileMakerPhp\":false}\n"+
+		"PATCH /fmi/admin/api/v2/xml/config {\"enabled\":true}\n", outStream.String())
+
+	for _, request := range requests {
+		assert.NotContains(t, request, "PATCH")
+	}
+}
+
+func TestRunOpenCommandWithEncryptedDatabase(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:16001/fmi/admin/api/v2/user/auth")
+	if err == nil {
+		t.Skip("a server is running")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+
+	// Invoices.fmp12 opens, and Contacts.fmp12 stays closed with a wrong key
+	status := "CLOSED"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" && strings.HasSuffix(r.URL.Path, "/databases/2") {
+			status = "NORMAL"
+		}
+		fmt.Fprintln(w, "{\"response\": {\"token\": \"ACCESSTOKEN\", \"databases\": ["+
+			"{\"id\": \"1\", \"filename\": \"Contacts.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \"CLOSED\", \"isEncrypted\": true, \"decryptHint\": \"contact hint\"},"+
+			"{\"id\": \"2\", \"filename\": \"Invoices.fmp12\", \"folder\": \"filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/\", \"status\": \""+status+"\", \"isEncrypted\": false}"+
+			"]}, \"messages\": [{\"code\": \"0\"}]}")
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:16001")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ts := httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: handler},
+	}
+	ts.Start()
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	exitStatus := cli.Run(strings.Split("fmcsadmin -u USERNAME -p PASSWORD -y open --key WRONG Contacts Invoices", " "))
+	assert.Equal(t, 802, exitStatus)
+	assert.Contains(t, outStream.String(), "File Closed: Contacts.fmp12")
+}
+
+func TestRetryEncryptedDatabases(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": {"databases": [`+
+			`{"id": "1", "filename": "Invoices.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "CLOSED", "isEncrypted": true, "decryptHint": "invoice hint"},`+
+			`{"id": "2", "filename": "Contacts.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "CLOSED", "isEncrypted": true, "decryptHint": ""},`+
+			`{"id": "3", "filename": "Products.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "CLOSED", "isEncrypted": false},`+
+			`{"id": "4", "filename": "Orders.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "NORMAL", "isEncrypted": true}`+
+			`]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	client := fmsadmin.NewClient(ts.URL)
+	idList := []int{1, 2, 3, 4}
+	nameList := []string{"Invoices.fmp12", "Contacts.fmp12", "Products.fmp12", "Orders.fmp12"}
+	hintList := []string{"invoice hint", "", "", ""}
+	retryEncryptedDatabases(cli, client, idList, nameList, hintList, make([]bool, 4), []int{802, 20408, 20408, 0}, false)
+	assert.Equal(t, "File(s) still needing the encryption password:\n"+
+		"    Invoices.fmp12 (Hint: invoice hint)\n"+
+		"    Contacts.fmp12 (Hint: )\n", outStream.String())
+
+	outStream.Reset()
+	retryEncryptedDatabases(cli, client, idList, nameList, hintList, make([]bool, 4), []int{0, 0, 0, 0}, false)
+	assert.Equal(t, "", outStream.String())
+}
+
+func TestDrainDatabases(t *testing.T) {
+	interval := drainInterval
+	drainInterval = 10 * time.Millisecond
+	defer func() { drainInterval = interval }()
+
+	// the client is connected to DB1 for the first polls
+	polls := 0
+	requests := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != "GET":
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+			fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "0"}]}`)
+		case strings.HasSuffix(r.URL.Path, "/blocknewusers"):
+			fmt.Fprintln(w, `{"response": {"blockNewUsers": false}, "messages": [{"code": "0"}]}`)
+		case strings.HasSuffix(r.URL.Path, "/clients"):
+			polls++
+			clients := ""
+			if polls <= 4 {
+				clients = `{"id": "7", "status": "NORMAL", "userName": "USER", "guestFiles": [{"id": "1", "filename": "DB1.fmp12"}]}`
+			}
+			fmt.Fprintln(w, `{"response": {"clients": [`+clients+`]}, "messages": [{"code": "0"}]}`)
+		}
+	}))
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	client := fmsadmin.NewClient(ts.URL)
+	results := drainDatabases(cli, client, []int{1, 2}, []string{"DB1.fmp12", "DB2.fmp12"}, "", false, true, time.Minute, 20*time.Millisecond)
+	assert.Equal(t, []int{0, 0}, results)
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/server/config/blocknewusers {\"blockNewUsers\":true}", requests[0])
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/databases/2 {\"status\":\"CLOSED\",\"messageText\":\"\",\"force\":false}", requests[1])
+	assert.Equal(t, "POST /fmi/admin/api/v2/clients/7/message {\"messageText\":\""+drainReminder+"\"}", requests[2])
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/databases/1 {\"status\":\"CLOSED\",\"messageText\":\"\",\"force\":false}", requests[len(requests)-2])
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/server/config/blocknewusers {\"blockNewUsers\":false}", requests[len(requests)-1])
+	assert.Contains(t, outStream.String(), "File Closed: DB1.fmp12")
+	assert.NotContains(t, outStream.String(), "Drain Timed Out")
+	assert.Contains(t, outStream.String(), "If draining is aborted, allow new users with: fmcsadmin set serverprefs blocknewusersenabled=false\n")
+	assert.Contains(t, outStream.String(), "New users are allowed.\n")
+
+	// the client does not disconnect from DB1 before the timeout
+	polls = -1000
+	requests = []string{}
+	outStream.Reset()
+	results = drainDatabases(cli, client, []int{1}, []string{"DB1.fmp12"}, "Bye", true, false, 50*time.Millisecond, 20*time.Millisecond)
+	assert.Equal(t, []int{0}, results)
+	assert.Equal(t, "POST /fmi/admin/api/v2/clients/7/message {\"messageText\":\"Bye\"}", requests[0])
+	assert.Equal(t, "PATCH /fmi/admin/api/v2/databases/1 {\"status\":\"CLOSED\",\"messageText\":\"Bye\",\"force\":true}", requests[len(requests)-1])
+	assert.Contains(t, outStream.String(), "New users cannot be blocked on this server.")
+	assert.Contains(t, outStream.String(), "Drain Timed Out: DB1.fmp12")
+}
+
+func TestParseClientFilter(t *testing.T) {
+	conditions, err := parseClientFilter("ip in 10.2.0.0/16,192.168.0.5 and duration > 8h")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(conditions))
+	assert.Equal(t, "in", conditions[0].operator)
+	assert.Equal(t, 2, len(conditions[0].networks))
+	assert.Equal(t, 8*time.Hour, conditions[1].duration)
+
+	for _, expr := range []string{"", " "} {
+		conditions, err = parseClientFilter(expr)
+		assert.Nil(t, conditions)
+		assert.NotNil(t, err)
+	}
+
+	conditions, err = parseClientFilter(`user='Guest*' AND database != "Test DB" and ip not in 10.0.0.0/8`)
+	assert.Nil(t, err)
+	assert.Equal(t, "Guest*", conditions[0].value)
+	assert.Equal(t, "Test DB", conditions[1].value)
+	assert.Equal(t, "not in", conditions[2].operator)
+
+	for _, expr := range []string{"name = admin", "duration = 8h", "ip in 10.2.0.0/33", "duration > 8x", "user =", "user = admin or ip = 10.0.0.1", "user = [admin"} {
+		_, err = parseClientFilter(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
+
+func TestMatchClient(t *testing.T) {
+	v := fmsadmin.ConnectedClient{
+		ID:              3,
+		UserName:        "Guest User",
+		ComputerName:    "PC-01",
+		IPAddress:       "10.2.3.4",
+		ConnectDuration: "9:01:02",
+		AppVersion:      "Pro 21.0.1",
+		GuestFiles:      []fmsadmin.GuestFile{{Filename: "Invoices.fmp12", AccountName: "Admin"}},
+	}
+
+	for expr, expected := range map[string]bool{
+		"ip in 10.2.0.0/16 and duration > 8h":              true,
+		"ip in 10.2.0.0/16 and duration > 10h":             false,
+		"ip = 10.2.3.4":                                    true,
+		"ip not in 10.2.0.0/16":                            false,
+		"user = 'guest*'":                                  true,
+		"user != 'guest*'":                                 false,
+		"account = admin and computer = PC-01":             true,
+		"database = Invoices":                              true,
+		"database = 'Inv*'":                                true,
+		"database != Invoices":                             false,
+		"app = '*21.0*' and duration <= 9h":                false,
+		"app = '*21.0*' and duration >= 9h":                true,
+		"database = Contacts or user = admin":              false,
+		"duration < 10h and ip in 192.168.0.0/24":          false,
+		"duration < 10h and ip in 192.168.0.0/24,10.2.3.4": true,
+	} {
+		conditions, err := parseClientFilter(expr)
+		if err != nil {
+			assert.Equal(t, false, expected, expr)
+			continue
+		}
+		assert.Equal(t, expected, matchClient(conditions, v), expr)
+	}
+
+	duration, ok := parseConnectDuration("1:02:03:04")
+	assert.True(t, ok)
+	assert.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second, duration)
+	_, ok = parseConnectDuration("")
+	assert.False(t, ok)
+}
+
+func TestSendMatchingMessages(t *testing.T) {
+	requests := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			requests = append(requests, r.URL.Path)
+			if strings.Contains(r.URL.Path, "/clients/4/") {
+				fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "11005", "text": "Client does not exist"}]}`)
+				return
+			}
+			fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "0"}]}`)
+			return
+		}
+		fmt.Fprintln(w, `{"response": {"clients": [`+
+			`{"id": "2", "status": "NORMAL", "userName": "PRO", "computerName": "PC", "extpriv": "fmapp", "appVersion": "Pro 21.0.1", "guestFiles": [{"filename": "Invoices.fmp12", "accountName": "Sales", "privsetName": "[Data Entry Only]"}]},`+
+			`{"id": "3", "status": "NORMAL", "userName": "GO", "computerName": "iPhone", "extpriv": "fmapp", "appVersion": "Go 21.0.1", "guestFiles": [{"filename": "Invoices.fmp12", "accountName": "Sales", "privsetName": "[Data Entry Only]"}]},`+
+			`{"id": "4", "status": "NORMAL", "userName": "WEB", "computerName": "Browser", "extpriv": "fmwebdirect", "appVersion": "Chrome", "guestFiles": [{"filename": "Invoices.fmp12", "accountName": "Admin", "privsetName": "[Full Access]"}]},`+
+			`{"id": "5", "status": "DISCONNECTING", "userName": "OLD", "computerName": "PC2", "extpriv": "fmapp", "appVersion": "Pro 21.0.1", "guestFiles": [{"filename": "Invoices.fmp12", "accountName": "Sales", "privsetName": "[Data Entry Only]"}]}`+
+			`]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream, output: "csv", noHeaders: true}
+	client := fmsadmin.NewClient(ts.URL)
+
+	conditions, _ := parseClientFilter("account = Sales")
+	exitStatus := sendMatchingMessages(cli, client, "Hello", []int{2, 3, 4}, -1, conditions)
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, []string{"/fmi/admin/api/v2/clients/2/message", "/fmi/admin/api/v2/clients/3/message"}, requests)
+	assert.Equal(t, "2,PRO,PC,0\n3,GO,iPhone,0\n", outStream.String())
+
+	requests = []string{}
+	outStream.Reset()
+	conditions, _ = parseClientFilter("type = webdirect")
+	exitStatus = sendMatchingMessages(cli, client, "Hello", []int{2, 3, 4}, -1, conditions)
+	assert.Equal(t, 11005, exitStatus)
+	assert.Equal(t, "4,WEB,Browser,11005 ("+getErrorDescription(11005)+")\n", outStream.String())
+
+	requests = []string{}
+	conditions, err := parseClientFilter("type = go and privset = '[Data Entry Only]'")
+	assert.Nil(t, err)
+	exitStatus = sendMatchingMessages(cli, client, "Hello", []int{2, 4}, -1, conditions)
+	assert.Equal(t, 10904, exitStatus)
+	assert.Equal(t, 0, len(requests))
+
+	exitStatus = sendMatchingMessages(cli, client, "Hello", []int{2, 3, 4}, -1, conditions)
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, []string{"/fmi/admin/api/v2/clients/3/message"}, requests)
+
+	// plain send reports every connected client and skips disconnecting ones
+	requests = []string{}
+	outStream.Reset()
+	exitStatus = sendMessages(cli, client, "Hello", []string{"send"}, -1, nil)
+	assert.Equal(t, 11005, exitStatus)
+	assert.Equal(t, []string{"/fmi/admin/api/v2/clients/2/message", "/fmi/admin/api/v2/clients/3/message", "/fmi/admin/api/v2/clients/4/message"}, requests)
+	assert.Equal(t, "2,PRO,PC,0\n3,GO,iPhone,0\n4,WEB,Browser,11005 ("+getErrorDescription(11005)+")\n", outStream.String())
+
+	// send -c 3
+	requests = []string{}
+	outStream.Reset()
+	exitStatus = sendMessages(cli, client, "Hello", []string{"send"}, 3, nil)
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, []string{"/fmi/admin/api/v2/clients/3/message"}, requests)
+	assert.Equal(t, "3,GO,iPhone,0\n", outStream.String())
+
+	// send -c 5 does not message a disconnecting client
+	requests = []string{}
+	exitStatus = sendMessages(cli, client, "Hello", []string{"send"}, 5, nil)
+	assert.Equal(t, 10904, exitStatus)
+	assert.Equal(t, 0, len(requests))
+
+	// send FILE
+	requests = []string{}
+	outStream.Reset()
+	exitStatus = sendMessages(cli, client, "Hello", []string{"send", "Invoices"}, -1, nil)
+	assert.Equal(t, 11005, exitStatus)
+	assert.Equal(t, 3, len(requests))
+	assert.Equal(t, "2,PRO,PC,0\n3,GO,iPhone,0\n4,WEB,Browser,11005 ("+getErrorDescription(11005)+")\n", outStream.String())
+
+	_, err = parseClientFilter("privset = '[Full*'")
+	assert.NotNil(t, err)
+
+	for appVersion, clientType := range map[string]string{"Pro 21.0.1": "pro", "ProAdvanced 19.6.3": "pro", "Go 21.0.1": "go", "FileMaker Go 20.3": "go", "ODBC": "other"} {
+		assert.Equal(t, clientType, getClientType(fmsadmin.ConnectedClient{AppVersion: appVersion}), appVersion)
+	}
+}
+
+func TestWaitForDatabases(t *testing.T) {
+	interval := waitInterval
+	waitInterval = 10 * time.Millisecond
+	defer func() { waitInterval = interval }()
+
+	// statuses of the databases returned by each request
+	polls := [][]string{
+		{"OPENING", "CLOSED", "CLOSED", "CLOSED", "CLOSED"},
+		{"NORMAL", "OPENING", "OPENING", "CLOSED", "CLOSED"},
+		{"NORMAL", "CLOSED", "OPENING", "CLOSED", "CLOSED"},
+	}
+	n := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses := polls[n]
+		if n < len(polls)-1 {
+			n++
+		}
+		databases := []string{}
+		for i, status := range statuses {
+			databases = append(databases, fmt.Sprintf(`{"id": "%d", "filename": "DB%d.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "%s"}`, i+1, i+1, status))
+		}
+		fmt.Fprintln(w, `{"response": {"databases": [`+strings.Join(databases, ",")+`]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	cli := &cli{outStream: outStream, errStream: errStream}
+	client := fmsadmin.NewClient(ts.URL)
+	idList := []int{1, 2, 3, 4, 5, 6}
+	nameList := []string{"DB1.fmp12", "DB2.fmp12", "DB3.fmp12", "DB4.fmp12", "DB5.fmp12", "DB6.fmp12"}
+	exitStatus := waitForDatabases(cli, client, idList, nameList, []int{0, 0, 0, 802, 0, 0}, "CLOSED", "NORMAL", 500*time.Millisecond)
+	assert.Equal(t, -1, exitStatus)
+	assert.Equal(t, "DB1.fmp12: NORMAL\n"+
+		"DB2.fmp12: Failed (CLOSED)\n"+
+		"DB3.fmp12: Timed out (OPENING)\n"+
+		"DB4.fmp12: Error: 802 (Unable to open the file)\n"+
+		"DB5.fmp12: Failed (CLOSED)\n"+
+		"DB6.fmp12: Failed (not found)\n", outStream.String())
+
+	n = 0
+	outStream.Reset()
+	exitStatus = waitForDatabases(cli, client, idList[:1], nameList[:1], []int{0}, "CLOSED", "NORMAL", 200*time.Millisecond)
+	assert.Equal(t, 0, exitStatus)
+	assert.Equal(t, "DB1.fmp12: NORMAL\n", outStream.String())
+
+	n = 0
+	outStream.Reset()
+	exitStatus = waitForDatabases(cli, client, idList[1:3], nameList[1:3], []int{0, 0}, "CLOSED", "NORMAL", 200*time.Millisecond)
+	assert.Equal(t, -1, exitStatus)
+
+	// the server reports an error
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "956", "text": "Maximum number of Admin API sessions exceeded"}]}`)
+	}))
+	defer ts.Close()
+	outStream.Reset()
+	exitStatus = waitForDatabases(cli, fmsadmin.NewClient(ts.URL), idList[:1], nameList[:1], []int{0}, "CLOSED", "NORMAL", time.Minute)
+	assert.Equal(t, -1, exitStatus)
+	assert.Equal(t, "DB1.fmp12: Failed (error 956)\n", outStream.String())
+}
+
+func TestSelectDatabases(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		databases := []string{}
+		for i, name := range []string{"Invoices_2024", "Invoices_2025", "Archive_2023", "Archive_Old", "TestDB", "Sales [2024]"} {
+			databases = append(databases, fmt.Sprintf(`{"id": "%d", "filename": "%s.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "NORMAL"}`, i+1, name))
+		}
+		fmt.Fprintln(w, `{"response": {"databases": [`+strings.Join(databases, ",")+`]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+	client := fmsadmin.NewClient(ts.URL)
+
+	idList, nameList, _ := selectDatabases(client, []string{"Invoices_*"}, "NORMAL", false, databaseSelector{})
+	assert.Equal(t, []int{1, 2}, idList)
+	assert.Equal(t, []string{"Invoices_2024.fmp12", "Invoices_2025.fmp12"}, nameList)
+
+	idList, _, _ = selectDatabases(client, []string{"Invoices_*", "Invoices_2024"}, "NORMAL", false, databaseSelector{})
+	assert.Equal(t, []int{1, 2}, idList)
+
+	idList, _, _ = selectDatabases(client, []string{""}, "NORMAL", false, databaseSelector{match: regexp.MustCompile(`^Archive_\d{4}$`)})
+	assert.Equal(t, []int{3}, idList)
+
+	idList, _, _ = selectDatabases(client, []string{""}, "NORMAL", false, databaseSelector{exclude: "Test*"})
+	assert.Equal(t, []int{1, 2, 3, 4, 6}, idList)
+
+	idList, _, _ = selectDatabases(client, []string{"*_20??"}, "NORMAL", false, databaseSelector{exclude: "Invoices_2025"})
+	assert.Equal(t, []int{1, 3}, idList)
+
+	idList, _, _ = selectDatabases(client, []string{"*"}, "CLOSED", false, databaseSelector{})
+	assert.Equal(t, 0, len(idList))
+
+	// the exact name of a database is not a pattern
+	for _, name := range []string{"Sales [2024].fmp12", "Sales [2024]"} {
+		idList, nameList, _, patternUsed := selectDatabasesWithPatterns(client, []string{name}, "NORMAL", false, databaseSelector{})
+		assert.Equal(t, []int{6}, idList)
+		assert.Equal(t, []string{"Sales [2024].fmp12"}, nameList)
+		assert.False(t, patternUsed)
+	}
+
+	idList, _, _, patternUsed := selectDatabasesWithPatterns(client, []string{"Invoices_202[4]"}, "NORMAL", false, databaseSelector{})
+	assert.Equal(t, []int{1}, idList)
+	assert.True(t, patternUsed)
+}
+
+func TestMatchGlobPattern(t *testing.T) {
+	assert.Equal(t, true, isGlobPattern("Invoices_*"))
+	assert.Equal(t, true, isGlobPattern("Test?"))
+	assert.Equal(t, false, isGlobPattern("TestDB"))
+	assert.Equal(t, false, isGlobPattern("filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/Test*/"))
+
+	assert.Equal(t, true, matchGlobPattern("Invoices_*", "Invoices_2024.fmp12"))
+	assert.Equal(t, true, matchGlobPattern("Invoices_*.fmp12", "Invoices_2024.fmp12"))
+	assert.Equal(t, true, matchGlobPattern("Invoices_202[45]", "Invoices_2024.fmp12"))
+	assert.Equal(t, false, matchGlobPattern("Invoices_*", "Archive_2024.fmp12"))
+	assert.Equal(t, false, matchGlobPattern("Invoices_?", "Invoices_2024.fmp12"))
+}
+
 func TestComparePath(t *testing.T) {
 	assert.Equal(t, false, comparePath("TestDB", "TestDB2"))
 
@@ -1795,6 +3089,34 @@ func TestGetErrorDescription(t *testing.T) {
 	assert.Equal(t, "Invalid session error", getErrorDescription(25006))
 }
 
+func TestListSchedulesOfCloud(t *testing.T) {
+	local := time.Local
+	defer func() { time.Local = local }()
+	time.Local = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": {"schedules": [`+
+			`{"id": "2", "name": "Daily", "lastRun": "2006-01-02T15:04:05", "nextRun": "2006-01-03T15:04:05", "enabled": true, "status": "IDLE", "backupType": {}}`+
+			`]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	// connect to the test server instead of the FileMaker Cloud host
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = true
+
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{outStream: outStream, errStream: errStream}
+	client := fmsadmin.NewClient("https://example.account.filemaker-cloud.com")
+	client.HTTPClient = &http.Client{Transport: transport}
+	assert.Equal(t, 0, listSchedules(c, client, 0))
+	assert.Contains(t, outStream.String(), "2006/01/03 00:04")
+	assert.Contains(t, outStream.String(), "2006/01/04 00:04")
+}
+
 func TestGetDateTimeStringOfCurrentTimeZone(t *testing.T) {
 	const location = "Asia/Tokyo"
 	loc, err := time.LoadLocation(location)
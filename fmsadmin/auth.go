@@ -0,0 +1,60 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/base64"
+)
+
+// Login creates an access token with the user name and the password of
+// the Admin Console.
+func (c *Client) Login(username string, password string) error {
+	return c.login("Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+}
+
+// LoginPKI creates an access token with a JSON Web Token signed by the
+// private key for public key infrastructure (PKI) authentication.
+func (c *Client) LoginPKI(jwtToken string) error {
+	return c.login("PKI " + jwtToken)
+}
+
+func (c *Client) login(authorization string) error {
+	data, statusCode, err := c.send("POST", c.URL("/user/auth"), authorization, nil)
+	if err != nil {
+		return err
+	}
+
+	res := struct {
+		Token string `json:"token"`
+	}{}
+	err = decodeResponse(data, statusCode, &res)
+	if err != nil {
+		return err
+	}
+	c.Token = res.Token
+
+	return nil
+}
+
+// Logout invalidates the access token.
+func (c *Client) Logout() error {
+	err := c.do("DELETE", "/user/auth/"+c.Token, nil, nil)
+	c.Token = ""
+
+	return err
+}
@@ -0,0 +1,51 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/base64"
+)
+
+type csr struct {
+	Subject  string `json:"subject"`
+	Password string `json:"password"`
+}
+
+type certificate struct {
+	Certificate              string `json:"certificate"`
+	PrivateKey               string `json:"privateKey"`
+	IntermediateCertificates string `json:"intermediateCertificates"`
+	Password                 string `json:"password"`
+}
+
+// CreateCSR creates a private key file and a certificate signing request
+// for the subject. password is used to encrypt the private key file.
+func (c *Client) CreateCSR(subject string, password string) error {
+	return c.do("PATCH", "/server/certificate/csr", csr{base64.StdEncoding.EncodeToString([]byte(subject)), password}, nil)
+}
+
+// ImportCertificate imports the SSL certificate, the private key and the
+// intermediate certificates. All of them are PEM encoded.
+func (c *Client) ImportCertificate(cert string, privateKey string, intermediateCertificates string, password string) error {
+	return c.do("PATCH", "/server/certificate/import", certificate{cert, privateKey, intermediateCertificates, password}, nil)
+}
+
+// DeleteCertificate deletes the imported SSL certificate.
+func (c *Client) DeleteCertificate() error {
+	return c.do("DELETE", "/server/certificate/delete", nil, nil)
+}
@@ -0,0 +1,303 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fmsadmin is a client library for Claris FileMaker Admin API.
+package fmsadmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BasePath is the path of FileMaker Admin API v2.
+const BasePath = "/fmi/admin/api/v2"
+
+// DefaultTimeout is the default time limit of a request.
+const DefaultTimeout = 5 * time.Second
+
+// ErrInvalidResponse is returned when the server replies with a body that is
+// not a FileMaker Admin API response.
+var ErrInvalidResponse = errors.New("fmsadmin: invalid response")
+
+// ErrDryRun is returned instead of sending a request that changes the state
+// of the server when DryRun of the client is set.
+var ErrDryRun = errors.New("fmsadmin: dry run")
+
+// Error is returned when FileMaker Admin API reports an error.
+type Error struct {
+	StatusCode int    // HTTP status code
+	Code       int    // result code of FileMaker Admin API
+	Message    string // message text returned by the server
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("fmsadmin: error %d (%s)", e.Code, e.Message)
+	}
+	return fmt.Sprintf("fmsadmin: error %d", e.Code)
+}
+
+// Client is a client of FileMaker Admin API.
+type Client struct {
+	// BaseURL is the scheme, the host and the optional path prefix of the
+	// server (e.g. "http://127.0.0.1:16001" or
+	// "https://proxy.example.jp:8443/fms-admin").
+	BaseURL string
+
+	// Token is the access token issued by Login or LoginPKI.
+	Token string
+
+	// HTTPClient is used to send requests to the server.
+	HTTPClient *http.Client
+
+	// RetryPolicy is applied to every request of FileMaker Admin API.
+	RetryPolicy RetryPolicy
+
+	// DryRun, if not nil, receives the method, the path and the body of
+	// each request that changes the state of the server instead of the
+	// server. Login and Logout are sent as usual.
+	DryRun io.Writer
+}
+
+// RetryPolicy is the policy to retry idempotent (GET) requests when the
+// server is unreachable, replies with a 5xx status code, or is stopping
+// (error 1701). The delay before each retry doubles up to MaxDelay.
+type RetryPolicy struct {
+	Retries      int           // maximum number of retries
+	InitialDelay time.Duration // delay before the first retry
+	MaxDelay     time.Duration // upper limit of the delay
+}
+
+// DefaultRetryPolicy does not retry requests.
+var DefaultRetryPolicy = RetryPolicy{
+	Retries:      0,
+	InitialDelay: time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// Delay returns the delay before the nth retry (starting from 1).
+func (p RetryPolicy) Delay(n int) time.Duration {
+	delay := p.InitialDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+type response struct {
+	Response json.RawMessage `json:"response"`
+	Messages []struct {
+		Code string `json:"code"`
+		Text string `json:"text"`
+	} `json:"messages"`
+}
+
+// NewClient returns a client of the server specified by baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:     baseURL,
+		HTTPClient:  &http.Client{Timeout: DefaultTimeout},
+		RetryPolicy: DefaultRetryPolicy,
+	}
+}
+
+// URL returns the URL of the endpoint. The endpoint is relative to BasePath
+// under the path prefix of BaseURL and may contain a query string
+// (e.g. "/clients/2?graceTime=90").
+func (c *Client) URL(endpoint string) string {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return ""
+	}
+
+	query := ""
+	if i := strings.Index(endpoint, "?"); i >= 0 {
+		endpoint, query = endpoint[:i], endpoint[i+1:]
+	}
+	u.Path = path.Join("/", u.Path, BasePath, endpoint)
+	u.RawQuery = query
+
+	return u.String()
+}
+
+// Call sends a request to the endpoint with the access token, and returns
+// the response body and the HTTP status code without interpreting them.
+func (c *Client) Call(method string, endpoint string, body io.Reader) ([]byte, int, error) {
+	if c.DryRun != nil && method != http.MethodGet && !strings.HasPrefix(endpoint, "/user/auth/") {
+		return nil, 0, c.dryRun(method, endpoint, body)
+	}
+
+	token := strings.Replace(strings.Replace(c.Token, "\n", "", -1), "\r", "", -1)
+
+	return c.send(method, c.URL(endpoint), "Bearer "+token, body)
+}
+
+// dryRun prints the request to DryRun in the form of
+// "PATCH /fmi/admin/api/v2/databases/1 {"status":"PAUSED"}".
+func (c *Client) dryRun(method string, endpoint string, body io.Reader) error {
+	requestURI := c.URL(endpoint)
+	if u, err := url.Parse(requestURI); err == nil {
+		requestURI = u.RequestURI()
+	}
+
+	line := method + " " + requestURI
+	if body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		line += " " + string(data)
+	}
+	if _, err := fmt.Fprintln(c.DryRun, line); err != nil {
+		return err
+	}
+
+	return ErrDryRun
+}
+
+func (c *Client) send(method string, urlString string, authorization string, body io.Reader) ([]byte, int, error) {
+	data, statusCode, err := c.sendOnce(method, urlString, authorization, body)
+	if method != http.MethodGet {
+		return data, statusCode, err
+	}
+
+	for n := 1; n <= c.RetryPolicy.Retries && isRetryable(data, statusCode, err); n++ {
+		time.Sleep(c.RetryPolicy.Delay(n))
+		data, statusCode, err = c.sendOnce(method, urlString, authorization, body)
+	}
+
+	return data, statusCode, err
+}
+
+func isRetryable(data []byte, statusCode int, err error) bool {
+	if err != nil || statusCode >= 500 {
+		return true
+	}
+
+	// the database server is stopping
+	res := response{}
+	if json.Unmarshal(data, &res) == nil && len(res.Messages) > 0 {
+		return res.Messages[0].Code == "1701"
+	}
+
+	return false
+}
+
+func (c *Client) sendOnce(method string, urlString string, authorization string, body io.Reader) ([]byte, int, error) {
+	req, err := http.NewRequest(method, urlString, body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if body == nil {
+		req.Header.Set("Content-Length", "0")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authorization)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, res.StatusCode, err
+	}
+
+	return data, res.StatusCode, nil
+}
+
+func (c *Client) do(method string, endpoint string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	data, statusCode, err := c.Call(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(data, statusCode, out)
+}
+
+func decodeResponse(data []byte, statusCode int, out interface{}) error {
+	res := response{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		if statusCode >= 400 {
+			return &Error{StatusCode: statusCode, Code: -1}
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidResponse, err.Error())
+	}
+
+	if len(res.Messages) == 0 {
+		if statusCode >= 400 {
+			return &Error{StatusCode: statusCode, Code: -1}
+		}
+		return fmt.Errorf("%w: no result code", ErrInvalidResponse)
+	}
+
+	code, _ := strconv.Atoi(res.Messages[0].Code)
+	if statusCode >= 400 || code != 0 {
+		return &Error{StatusCode: statusCode, Code: code, Message: res.Messages[0].Text}
+	}
+
+	if out != nil && len(res.Response) > 0 {
+		if err := json.Unmarshal(res.Response, out); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidResponse, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// requireFields returns an error when the JSON object lacks any of the
+// fields, so that a missing or renamed field of a response is detected
+// instead of being decoded as the zero value.
+func requireFields(data []byte, names ...string) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing field %q", name)
+		}
+	}
+
+	return nil
+}
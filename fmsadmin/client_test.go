@@ -0,0 +1,280 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fmsadmin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURL(t *testing.T) {
+	c := NewClient("https://example.jp")
+	assert.Equal(t, "https://example.jp/fmi/admin/api/v2/databases", c.URL("/databases"))
+	assert.Equal(t, "https://example.jp/fmi/admin/api/v2/clients/2?messageText=TEST&graceTime=90", c.URL("/clients/2?messageText=TEST&graceTime=90"))
+
+	c = NewClient("https://example.jp:8443/fms-admin/")
+	assert.Equal(t, "https://example.jp:8443/fms-admin/fmi/admin/api/v2/databases", c.URL("/databases"))
+}
+
+func TestLogin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/fmi/admin/api/v2/user/auth", r.URL.Path)
+		assert.Equal(t, "Basic VVNFUk5BTUU6UEFTU1dPUkQ=", r.Header.Get("Authorization"))
+		fmt.Fprintln(w, `{"response": {"token": "ACCESSTOKEN"}, "messages": [{"code": "0", "text": "OK"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	err := c.Login("USERNAME", "PASSWORD")
+	assert.Nil(t, err)
+	assert.Equal(t, "ACCESSTOKEN", c.Token)
+}
+
+func TestLoginFailed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "212", "text": "Invalid user account and/or password; please try again"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	err := c.Login("USERNAME", "PASSWORD")
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, 212, apiErr.Code)
+	assert.Equal(t, "", c.Token)
+}
+
+func TestListDatabases(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/fmi/admin/api/v2/databases", r.URL.Path)
+		assert.Equal(t, "Bearer ACCESSTOKEN", r.Header.Get("Authorization"))
+		fmt.Fprintln(w, `{"response": {"totalDBCount": 2, "databases": [{"id": "1", "filename": "TestDB.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "NORMAL", "clients": 2, "size": 1048576, "isEncrypted": false, "enabledExtPrivileges": ["fmapp"]}, {"id": "2", "filename": "Encrypted.fmp12", "folder": "filelinux:/opt/FileMaker/FileMaker Server/Data/Databases/", "status": "CLOSED", "isEncrypted": true, "decryptHint": "HINT"}]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	c.Token = "ACCESSTOKEN"
+	databases, err := c.ListDatabases()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(databases))
+	assert.Equal(t, 1, databases[0].ID)
+	assert.Equal(t, "TestDB.fmp12", databases[0].Filename)
+	assert.Equal(t, 2, databases[0].Clients)
+	assert.Equal(t, int64(1048576), databases[0].Size)
+	assert.Equal(t, []string{"fmapp"}, databases[0].EnabledExtPrivileges)
+	assert.Equal(t, 2, databases[1].ID)
+	assert.True(t, databases[1].IsEncrypted)
+	assert.Equal(t, "HINT", databases[1].DecryptHint)
+}
+
+func TestListClients(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/fmi/admin/api/v2/clients", r.URL.Path)
+		fmt.Fprintln(w, `{"response": {"clients": [{"id": "3", "status": "NORMAL", "userName": "USER", "computerName": "PC", "extpriv": "fmapp", "ipaddress": "192.168.0.2", "guestFiles": [{"id": "1", "filename": "TestDB.fmp12", "accountName": "Admin", "privsetName": "[Full Access]"}, {"id": "2", "filename": "Sub.fmp12", "accountName": "Guest", "privsetName": "[Read-Only Access]"}]}]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	clients, err := c.ListClients()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(clients))
+	assert.Equal(t, 3, clients[0].ID)
+	assert.Equal(t, "192.168.0.2", clients[0].IPAddress)
+	assert.Equal(t, 2, len(clients[0].GuestFiles))
+	assert.Equal(t, "Sub.fmp12", clients[0].GuestFiles[1].Filename)
+	assert.Equal(t, "[Read-Only Access]", clients[0].GuestFiles[1].PrivsetName)
+}
+
+func TestListSchedules(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/fmi/admin/api/v2/schedules", r.URL.Path)
+		fmt.Fprintln(w, `{"response": {"schedules": [{"id": "1", "name": "Daily", "lastRun": "2026-01-01T00:00:00", "nextRun": "2026-01-02T00:00:00", "enabled": true, "status": "IDLE", "backupType": {"resourceType": "ALL_DB"}}, {"id": "2", "name": "Script", "lastRun": "", "nextRun": "", "enabled": false, "status": "IDLE", "systemScriptType": {"osScript": "filelinux:/script.sh"}}]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	schedules, err := c.ListSchedules()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(schedules))
+	assert.Equal(t, 1, schedules[0].ID)
+	assert.Equal(t, "Backup", schedules[0].TaskType())
+	assert.Equal(t, "ALL_DB", schedules[0].BackupType.ResourceType)
+	assert.Equal(t, "System Script", schedules[1].TaskType())
+	assert.Equal(t, "filelinux:/script.sh", schedules[1].SystemScriptType.OsScript)
+	assert.False(t, schedules[1].Enabled)
+}
+
+func TestListPlugins(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/fmi/admin/api/v2/plugins", r.URL.Path)
+		fmt.Fprintln(w, `{"response": {"plugins": [{"id": "1", "pluginName": "Sample", "filename": "Sample.fmplugin", "enabled": true}]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	plugins, err := c.ListPlugins()
+	assert.Nil(t, err)
+	assert.Equal(t, []Plugin{{ID: 1, PluginName: "Sample", Filename: "Sample.fmplugin", Enabled: true}}, plugins)
+}
+
+func TestGetServerMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/fmi/admin/api/v2/server/metadata", r.URL.Path)
+		fmt.Fprintln(w, `{"response": {"ServerVersion": "22.0.1.68"}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	metadata, err := c.GetServerMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, "22.0.1.68", metadata.ServerVersion)
+}
+
+func TestMissingField(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// "filename" is renamed
+		fmt.Fprintln(w, `{"response": {"databases": [{"id": "1", "fileName": "TestDB.fmp12", "folder": "", "status": "NORMAL"}]}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	_, err := c.ListDatabases()
+	assert.True(t, errors.Is(err, ErrInvalidResponse))
+	assert.Contains(t, err.Error(), `missing field "filename"`)
+}
+
+func TestOpenDatabase(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/fmi/admin/api/v2/databases/1", r.URL.Path)
+		assert.Equal(t, `{"status":"OPENED","key":"KEY","saveKey":true}`, string(request))
+		fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	assert.Nil(t, c.OpenDatabase(1, "KEY", true))
+}
+
+func TestSetGeneralConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"cacheSize":512,"maxFiles":256,"maxProConnections":250,"maxPSOS":100}`, string(request))
+		fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	assert.Nil(t, c.SetGeneralConfig(GeneralConfig{CacheSize: 512, MaxFiles: 256, MaxProConnections: 250, MaxPSOS: 100}))
+}
+
+func TestDryRun(t *testing.T) {
+	requests := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		fmt.Fprintln(w, `{"response": {"databases": []}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	out := new(bytes.Buffer)
+	c := NewClient(ts.URL + "/fms-admin")
+	c.Token = "TOKEN"
+	c.DryRun = out
+	assert.True(t, errors.Is(c.CloseDatabase(1, "Closing", false), ErrDryRun))
+	assert.True(t, errors.Is(c.DisconnectClient(2, "", 90), ErrDryRun))
+	_, err := c.ListDatabases()
+	assert.Nil(t, err)
+	assert.Nil(t, c.Logout())
+	assert.Equal(t, "PATCH /fms-admin/fmi/admin/api/v2/databases/1 {\"status\":\"CLOSED\",\"messageText\":\"Closing\",\"force\":false}\n"+
+		"DELETE /fms-admin/fmi/admin/api/v2/clients/2?messageText=&graceTime=90\n", out.String())
+	assert.Equal(t, []string{"GET /fms-admin/fmi/admin/api/v2/databases", "DELETE /fms-admin/fmi/admin/api/v2/user/auth/TOKEN"}, requests)
+}
+
+func TestInvalidResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "<html></html>")
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	_, err := c.ListClients()
+	assert.True(t, errors.Is(err, ErrInvalidResponse))
+}
+
+func TestServerStopping(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "1701", "text": "Service is stopping"}]}`)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	_, err := c.ListClients()
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 1701, apiErr.Code)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := RetryPolicy{Retries: 5, InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+	assert.Equal(t, time.Second, p.Delay(1))
+	assert.Equal(t, 2*time.Second, p.Delay(2))
+	assert.Equal(t, 4*time.Second, p.Delay(3))
+	assert.Equal(t, 5*time.Second, p.Delay(4))
+	assert.Equal(t, 5*time.Second, p.Delay(10))
+}
+
+func TestRetry(t *testing.T) {
+	count := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		switch count {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			fmt.Fprintln(w, `{"response": {}, "messages": [{"code": "1701", "text": "Service is stopping"}]}`)
+		default:
+			fmt.Fprintln(w, `{"response": {"status": "RUNNING"}, "messages": [{"code": "0"}]}`)
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL)
+	c.RetryPolicy = RetryPolicy{Retries: 3, InitialDelay: time.Millisecond}
+	status, err := c.ServerStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, "RUNNING", status)
+	assert.Equal(t, 3, count)
+
+	// requests other than GET are not retried
+	count = 0
+	err = c.PauseDatabase(1)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,98 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// ConnectedClient is a client connected to the server.
+type ConnectedClient struct {
+	ID              int         `json:"id,string"`
+	Status          string      `json:"status"`
+	UserName        string      `json:"userName"`
+	ComputerName    string      `json:"computerName"`
+	ExtPriv         string      `json:"extpriv"`
+	IPAddress       string      `json:"ipaddress"`
+	MACAddress      string      `json:"macaddress"`
+	ConnectTime     string      `json:"connectTime"`
+	ConnectDuration string      `json:"connectDuration"`
+	AppVersion      string      `json:"appVersion"`
+	AppLanguage     string      `json:"appLanguage"`
+	GuestFiles      []GuestFile `json:"guestFiles"`
+}
+
+// GuestFile is a database opened by a connected client.
+type GuestFile struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	AccountName string `json:"accountName"`
+	PrivsetName string `json:"privsetName"`
+}
+
+// UnmarshalJSON decodes a connected client and reports the lack of required
+// fields.
+func (cc *ConnectedClient) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "id", "status", "userName"); err != nil {
+		return err
+	}
+
+	type connectedClient ConnectedClient
+	return json.Unmarshal(data, (*connectedClient)(cc))
+}
+
+// UnmarshalJSON decodes a guest file and reports the lack of required fields.
+func (g *GuestFile) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "filename"); err != nil {
+		return err
+	}
+
+	type guestFile GuestFile
+	return json.Unmarshal(data, (*guestFile)(g))
+}
+
+type message struct {
+	MessageText string `json:"messageText"`
+}
+
+// ListClients returns the clients connected to the server.
+func (c *Client) ListClients() ([]ConnectedClient, error) {
+	res := struct {
+		Clients []ConnectedClient `json:"clients"`
+	}{}
+	err := c.do("GET", "/clients", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Clients, nil
+}
+
+// DisconnectClient disconnects the client after graceTime seconds.
+func (c *Client) DisconnectClient(id int, message string, graceTime int) error {
+	query := "messageText=" + url.QueryEscape(message) + "&graceTime=" + url.QueryEscape(strconv.Itoa(graceTime))
+
+	return c.do("DELETE", "/clients/"+strconv.Itoa(id)+"?"+query, nil, nil)
+}
+
+// SendMessage sends the message to the client.
+func (c *Client) SendMessage(id int, text string) error {
+	return c.do("POST", "/clients/"+strconv.Itoa(id)+"/message", message{text}, nil)
+}
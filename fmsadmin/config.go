@@ -0,0 +1,266 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/json"
+)
+
+// GeneralConfig is the general configuration of the server.
+type GeneralConfig struct {
+	CacheSize         int `json:"cacheSize"`
+	MaxFiles          int `json:"maxFiles"`
+	MaxProConnections int `json:"maxProConnections"`
+	MaxPSOS           int `json:"maxPSOS"`
+
+	// for Claris FileMaker Server 19.1.1 or previous
+	StartupRestorationEnabled *bool `json:"startupRestorationEnabled,omitempty"`
+
+	// for Claris FileMaker Server 21.1.1 or later
+	OnlyOpenLastOpenedDatabases *bool `json:"onlyOpenLastOpenedDatabases,omitempty"`
+}
+
+// SecurityConfig is the security configuration of the server.
+type SecurityConfig struct {
+	RequireSecureDB bool `json:"requireSecureDB"`
+}
+
+// AuthenticatedStreamConfig is the setting of the authenticated stream.
+// for Claris FileMaker Server 19.3.2 or later
+type AuthenticatedStreamConfig struct {
+	AuthenticatedStream int `json:"authenticatedStream"`
+}
+
+// ParallelBackupConfig is the setting of the parallel backup.
+// for Claris FileMaker Server 19.5.1 or later
+type ParallelBackupConfig struct {
+	ParallelBackupEnabled bool `json:"parallelBackupEnabled"`
+}
+
+// PersistentCacheConfig is the setting of the persistent cache.
+// for Claris FileMaker Server 21.0.1 or later
+type PersistentCacheConfig struct {
+	PersistentCache           bool `json:"persistentCache"`
+	PersistentCacheSync       bool `json:"persistentCacheSync"`
+	DatabaseServerAutoRestart bool `json:"databaseServerAutoRestart"`
+}
+
+// BlockNewUsersConfig is the setting of blocking new users.
+// for Claris FileMaker Server 21.0.1 or later
+type BlockNewUsersConfig struct {
+	BlockNewUsers bool `json:"blockNewUsers"`
+}
+
+// HTTPSTunnelingConfig is the setting of HTTPS tunneling for FileMaker clients.
+// for Claris FileMaker Server 21.1.1 or later
+type HTTPSTunnelingConfig struct {
+	EnableHTTPSTunneling bool `json:"enableHTTPSTunneling"`
+}
+
+// PHPConfig is the configuration of Custom Web Publishing with PHP.
+type PHPConfig struct {
+	Enabled              bool   `json:"enabled"`
+	CharacterEncoding    string `json:"characterEncoding"`
+	ErrorMessageLanguage string `json:"errorMessageLanguage"`
+	DataPreValidation    bool   `json:"dataPreValidation"`
+	UseFileMakerPhp      bool   `json:"useFileMakerPhp"`
+}
+
+// XMLConfig is the configuration of Custom Web Publishing with XML.
+type XMLConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UnmarshalJSON decodes the general configuration and reports the lack of
+// required fields.
+func (g *GeneralConfig) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "cacheSize", "maxFiles", "maxProConnections", "maxPSOS"); err != nil {
+		return err
+	}
+
+	type generalConfig GeneralConfig
+	return json.Unmarshal(data, (*generalConfig)(g))
+}
+
+// UnmarshalJSON decodes the configuration of Custom Web Publishing with PHP
+// and reports the lack of required fields.
+func (p *PHPConfig) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "enabled", "characterEncoding", "errorMessageLanguage"); err != nil {
+		return err
+	}
+
+	type phpConfig PHPConfig
+	return json.Unmarshal(data, (*phpConfig)(p))
+}
+
+// UnmarshalJSON decodes the configuration of Custom Web Publishing with XML
+// and reports the lack of required fields.
+func (x *XMLConfig) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "enabled"); err != nil {
+		return err
+	}
+
+	type xmlConfig XMLConfig
+	return json.Unmarshal(data, (*xmlConfig)(x))
+}
+
+// GetGeneralConfig returns the general configuration of the server.
+func (c *Client) GetGeneralConfig() (*GeneralConfig, error) {
+	res := GeneralConfig{}
+	err := c.do("GET", "/server/config/general", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetGeneralConfig updates the general configuration of the server.
+func (c *Client) SetGeneralConfig(config GeneralConfig) error {
+	return c.do("PATCH", "/server/config/general", config, nil)
+}
+
+// GetSecurityConfig returns the security configuration of the server.
+func (c *Client) GetSecurityConfig() (*SecurityConfig, error) {
+	res := SecurityConfig{}
+	err := c.do("GET", "/server/config/security", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetSecurityConfig updates the security configuration of the server.
+func (c *Client) SetSecurityConfig(config SecurityConfig) error {
+	return c.do("PATCH", "/server/config/security", config, nil)
+}
+
+// GetAuthenticatedStreamConfig returns the setting of the authenticated
+// stream.
+func (c *Client) GetAuthenticatedStreamConfig() (*AuthenticatedStreamConfig, error) {
+	res := AuthenticatedStreamConfig{}
+	err := c.do("GET", "/server/config/authenticatedstream", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetAuthenticatedStreamConfig updates the setting of the authenticated
+// stream.
+func (c *Client) SetAuthenticatedStreamConfig(config AuthenticatedStreamConfig) error {
+	return c.do("PATCH", "/server/config/authenticatedstream", config, nil)
+}
+
+// GetParallelBackupConfig returns the setting of the parallel backup.
+func (c *Client) GetParallelBackupConfig() (*ParallelBackupConfig, error) {
+	res := ParallelBackupConfig{}
+	err := c.do("GET", "/server/config/parallelbackup", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetParallelBackupConfig updates the setting of the parallel backup.
+func (c *Client) SetParallelBackupConfig(config ParallelBackupConfig) error {
+	return c.do("PATCH", "/server/config/parallelbackup", config, nil)
+}
+
+// GetPersistentCacheConfig returns the setting of the persistent cache.
+func (c *Client) GetPersistentCacheConfig() (*PersistentCacheConfig, error) {
+	res := PersistentCacheConfig{}
+	err := c.do("GET", "/server/config/persistentcache", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetPersistentCacheConfig updates the setting of the persistent cache.
+func (c *Client) SetPersistentCacheConfig(config PersistentCacheConfig) error {
+	return c.do("PATCH", "/server/config/persistentcache", config, nil)
+}
+
+// GetBlockNewUsersConfig returns the setting of blocking new users.
+func (c *Client) GetBlockNewUsersConfig() (*BlockNewUsersConfig, error) {
+	res := BlockNewUsersConfig{}
+	err := c.do("GET", "/server/config/blocknewusers", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetBlockNewUsersConfig updates the setting of blocking new users.
+func (c *Client) SetBlockNewUsersConfig(config BlockNewUsersConfig) error {
+	return c.do("PATCH", "/server/config/blocknewusers", config, nil)
+}
+
+// GetHTTPSTunnelingConfig returns the setting of HTTPS tunneling.
+func (c *Client) GetHTTPSTunnelingConfig() (*HTTPSTunnelingConfig, error) {
+	res := HTTPSTunnelingConfig{}
+	err := c.do("GET", "/fmclients/httpstunneling", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetHTTPSTunnelingConfig updates the setting of HTTPS tunneling.
+func (c *Client) SetHTTPSTunnelingConfig(config HTTPSTunnelingConfig) error {
+	return c.do("PATCH", "/fmclients/httpstunneling", config, nil)
+}
+
+// GetPHPConfig returns the configuration of Custom Web Publishing with PHP.
+func (c *Client) GetPHPConfig() (*PHPConfig, error) {
+	res := PHPConfig{}
+	err := c.do("GET", "/php/config", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetPHPConfig updates the configuration of Custom Web Publishing with PHP.
+func (c *Client) SetPHPConfig(config PHPConfig) error {
+	return c.do("PATCH", "/php/config", config, nil)
+}
+
+// GetXMLConfig returns the configuration of Custom Web Publishing with XML.
+func (c *Client) GetXMLConfig() (*XMLConfig, error) {
+	res := XMLConfig{}
+	err := c.do("GET", "/xml/config", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetXMLConfig updates the configuration of Custom Web Publishing with XML.
+func (c *Client) SetXMLConfig(config XMLConfig) error {
+	return c.do("PATCH", "/xml/config", config, nil)
+}
@@ -0,0 +1,102 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Database is a database hosted on the server.
+type Database struct {
+	ID                   int      `json:"id,string"`
+	Filename             string   `json:"filename"`
+	Folder               string   `json:"folder"`
+	Status               string   `json:"status"`
+	Clients              int      `json:"clients"`
+	Size                 int64    `json:"size"`
+	IsEncrypted          bool     `json:"isEncrypted"`
+	DecryptHint          string   `json:"decryptHint"`
+	EnabledExtPrivileges []string `json:"enabledExtPrivileges"`
+}
+
+// UnmarshalJSON decodes a database and reports the lack of required fields.
+func (d *Database) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "id", "filename", "folder", "status"); err != nil {
+		return err
+	}
+
+	type database Database
+	return json.Unmarshal(data, (*database)(d))
+}
+
+type databaseStatus struct {
+	Status  string `json:"status"`
+	Key     string `json:"key"`
+	SaveKey bool   `json:"saveKey"`
+}
+
+type closingDatabaseStatus struct {
+	Status      string `json:"status"`
+	MessageText string `json:"messageText"`
+	Force       bool   `json:"force"`
+}
+
+type status struct {
+	Status string `json:"status"`
+}
+
+// ListDatabases returns the databases hosted on the server.
+func (c *Client) ListDatabases() ([]Database, error) {
+	res := struct {
+		Databases []Database `json:"databases"`
+	}{}
+	err := c.do("GET", "/databases", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Databases, nil
+}
+
+// OpenDatabase opens the database. key is the encryption password of an
+// encrypted database.
+func (c *Client) OpenDatabase(id int, key string, saveKey bool) error {
+	return c.do("PATCH", "/databases/"+strconv.Itoa(id), databaseStatus{"OPENED", key, saveKey}, nil)
+}
+
+// CloseDatabase closes the database after sending the message to the
+// connected clients.
+func (c *Client) CloseDatabase(id int, message string, force bool) error {
+	return c.do("PATCH", "/databases/"+strconv.Itoa(id), closingDatabaseStatus{"CLOSED", message, force}, nil)
+}
+
+// PauseDatabase pauses the database.
+func (c *Client) PauseDatabase(id int) error {
+	return c.do("PATCH", "/databases/"+strconv.Itoa(id), status{"PAUSED"}, nil)
+}
+
+// ResumeDatabase resumes the paused database.
+func (c *Client) ResumeDatabase(id int) error {
+	return c.do("PATCH", "/databases/"+strconv.Itoa(id), status{"RESUMED"}, nil)
+}
+
+// RemoveDatabase removes the closed database from the server.
+func (c *Client) RemoveDatabase(id int) error {
+	return c.do("DELETE", "/databases/"+strconv.Itoa(id), nil, nil)
+}
@@ -0,0 +1,53 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/json"
+)
+
+// Plugin is a plug-in installed on the server.
+type Plugin struct {
+	ID         int    `json:"id,string"`
+	PluginName string `json:"pluginName"`
+	Filename   string `json:"filename"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// UnmarshalJSON decodes a plug-in and reports the lack of required fields.
+func (p *Plugin) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "id", "pluginName", "filename", "enabled"); err != nil {
+		return err
+	}
+
+	type plugin Plugin
+	return json.Unmarshal(data, (*plugin)(p))
+}
+
+// ListPlugins returns the plug-ins installed on the server.
+func (c *Client) ListPlugins() ([]Plugin, error) {
+	res := struct {
+		Plugins []Plugin `json:"plugins"`
+	}{}
+	err := c.do("GET", "/plugins", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Plugins, nil
+}
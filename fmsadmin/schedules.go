@@ -0,0 +1,133 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Schedule is a schedule of the server. Only one of the task fields is set
+// according to the type of the schedule.
+type Schedule struct {
+	ID      int    `json:"id,string"`
+	Name    string `json:"name"`
+	LastRun string `json:"lastRun"`
+	NextRun string `json:"nextRun"`
+	Enabled bool   `json:"enabled"`
+	Status  string `json:"status"`
+
+	BackupType          *ScheduleTask `json:"backupType"`
+	FilemakerScriptType *ScheduleTask `json:"filemakerScriptType"`
+	MessageType         *ScheduleTask `json:"messageType"`
+	ScriptSequenceType  *ScheduleTask `json:"scriptSequenceType"`
+	SystemScriptType    *ScheduleTask `json:"systemScriptType"`
+	VerifyType          *ScheduleTask `json:"verifyType"`
+}
+
+// ScheduleTask is the task of a schedule.
+type ScheduleTask struct {
+	ResourceType string `json:"resourceType"`
+	Resource     string `json:"resource"`
+	OsScript     string `json:"osScript"`
+}
+
+// UnmarshalJSON decodes a schedule and reports the lack of required fields.
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "id", "name", "enabled", "status"); err != nil {
+		return err
+	}
+
+	type schedule Schedule
+	return json.Unmarshal(data, (*schedule)(s))
+}
+
+// TaskType returns the type of the schedule as displayed by FileMaker
+// Server Admin Console (e.g. "Backup").
+func (s Schedule) TaskType() string {
+	switch {
+	case s.BackupType != nil:
+		return "Backup"
+	case s.FilemakerScriptType != nil:
+		return "FileMaker Script"
+	case s.MessageType != nil:
+		return "Message"
+	case s.ScriptSequenceType != nil:
+		return "Script Sequence"
+	case s.SystemScriptType != nil:
+		return "System Script"
+	case s.VerifyType != nil:
+		return "Verify"
+	}
+
+	return ""
+}
+
+type scheduleSetting struct {
+	Enabled bool `json:"enabled"`
+}
+
+// ListSchedules returns the schedules of the server.
+func (c *Client) ListSchedules() ([]Schedule, error) {
+	res := struct {
+		Schedules []Schedule `json:"schedules"`
+	}{}
+	err := c.do("GET", "/schedules", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Schedules, nil
+}
+
+// GetSchedule returns the schedule.
+func (c *Client) GetSchedule(id int) (*Schedule, error) {
+	res := struct {
+		Schedule *Schedule `json:"schedule"`
+	}{}
+	err := c.do("GET", "/schedules/"+strconv.Itoa(id), nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Schedule == nil {
+		return nil, fmt.Errorf("%w: missing field %q", ErrInvalidResponse, "schedule")
+	}
+
+	return res.Schedule, nil
+}
+
+// RunSchedule runs the schedule now.
+func (c *Client) RunSchedule(id int) error {
+	return c.do("PATCH", "/schedules/"+strconv.Itoa(id), status{"RUNNING"}, nil)
+}
+
+// EnableSchedule enables the schedule.
+func (c *Client) EnableSchedule(id int) error {
+	return c.do("PATCH", "/schedules/"+strconv.Itoa(id), scheduleSetting{true}, nil)
+}
+
+// DisableSchedule disables the schedule.
+func (c *Client) DisableSchedule(id int) error {
+	return c.do("PATCH", "/schedules/"+strconv.Itoa(id), scheduleSetting{false}, nil)
+}
+
+// DeleteSchedule deletes the schedule.
+func (c *Client) DeleteSchedule(id int) error {
+	return c.do("DELETE", "/schedules/"+strconv.Itoa(id), nil, nil)
+}
@@ -0,0 +1,72 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"encoding/json"
+)
+
+// ServerMetadata is the metadata of the server.
+type ServerMetadata struct {
+	ServerVersion string `json:"ServerVersion"`
+}
+
+// UnmarshalJSON decodes the metadata and reports the lack of required fields.
+func (m *ServerMetadata) UnmarshalJSON(data []byte) error {
+	if err := requireFields(data, "ServerVersion"); err != nil {
+		return err
+	}
+
+	type serverMetadata ServerMetadata
+	return json.Unmarshal(data, (*serverMetadata)(m))
+}
+
+// GetServerMetadata returns the metadata of the server.
+func (c *Client) GetServerMetadata() (*ServerMetadata, error) {
+	res := ServerMetadata{}
+	err := c.do("GET", "/server/metadata", nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ServerStatus returns the status of the database server
+// ("RUNNING" or "STOPPED").
+func (c *Client) ServerStatus() (string, error) {
+	res := status{}
+	err := c.do("GET", "/server/status", nil, &res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Status, nil
+}
+
+// SetServerStatus starts ("RUNNING") or stops ("STOPPED") the database
+// server.
+func (c *Client) SetServerStatus(serverStatus string) error {
+	return c.do("PATCH", "/server/status", status{serverStatus}, nil)
+}
+
+// CancelBackup cancels the running backup. It requires Claris FileMaker
+// Server 19.5.1 or later.
+func (c *Client) CancelBackup() error {
+	return c.do("POST", "/server/cancelbackup", nil, nil)
+}
@@ -0,0 +1,113 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fmsadmin
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSOptions is the TLS settings to connect to the server.
+type TLSOptions struct {
+	// CACertFile is a PEM file of the CA certificates to verify the server
+	// certificate instead of the system certificate pool.
+	CACertFile string
+
+	// CertFile and KeyFile are PEM files of the client certificate and its
+	// private key.
+	CertFile string
+	KeyFile  string
+
+	// PinSHA256 is the base64-encoded SHA-256 hashes of the public key of
+	// the server certificate separated by ";" (e.g. "sha256//BASE64").
+	PinSHA256 string
+
+	// Insecure skips the verification of the server certificate.
+	Insecure bool
+}
+
+// Config returns the TLS configuration built from the options.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.Insecure,
+	}
+
+	if o.CACertFile != "" {
+		data, err := os.ReadFile(o.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", o.CACertFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("both of the client certificate and the private key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.PinSHA256 != "" {
+		pins := map[string]bool{}
+		for _, pin := range strings.Split(o.PinSHA256, ";") {
+			pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256//")
+			if pin != "" {
+				pins[pin] = true
+			}
+		}
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("no server certificate")
+			}
+			sum := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
+			if !pins[base64.StdEncoding.EncodeToString(sum[:])] {
+				return errors.New("public key of the server certificate does not match the pinned key")
+			}
+			return nil
+		}
+	}
+
+	return config, nil
+}
+
+// SetTLSOptions applies the TLS options to the HTTP client.
+func (c *Client) SetTLSOptions(o TLSOptions) error {
+	config, err := o.Config()
+	if err != nil {
+		return err
+	}
+
+	c.transport().TLSClientConfig = config
+
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fmsadmin
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTLSServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": {"token": "ACCESSTOKEN"}, "messages": [{"code": "0", "text": "OK"}]}`)
+	}))
+}
+
+func TestTLSOptions(t *testing.T) {
+	ts := newTLSServer()
+	defer ts.Close()
+
+	// the certificate of the test server is not trusted by default
+	c := NewClient(ts.URL)
+	assert.Nil(t, c.SetTLSOptions(TLSOptions{}))
+	assert.NotNil(t, c.Login("USERNAME", "PASSWORD"))
+
+	c = NewClient(ts.URL)
+	assert.Nil(t, c.SetTLSOptions(TLSOptions{Insecure: true}))
+	assert.Nil(t, c.Login("USERNAME", "PASSWORD"))
+
+	caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	assert.Nil(t, os.WriteFile(caCertFile, data, 0600))
+	c = NewClient(ts.URL)
+	assert.Nil(t, c.SetTLSOptions(TLSOptions{CACertFile: caCertFile}))
+	assert.Nil(t, c.Login("USERNAME", "PASSWORD"))
+
+	sum := sha256.Sum256(ts.Certificate().RawSubjectPublicKeyInfo)
+	pin := "sha256//" + base64.StdEncoding.EncodeToString(sum[:])
+	c = NewClient(ts.URL)
+	assert.Nil(t, c.SetTLSOptions(TLSOptions{Insecure: true, PinSHA256: pin}))
+	assert.Nil(t, c.Login("USERNAME", "PASSWORD"))
+
+	c = NewClient(ts.URL)
+	assert.Nil(t, c.SetTLSOptions(TLSOptions{Insecure: true, PinSHA256: "sha256//AAAA"}))
+	assert.NotNil(t, c.Login("USERNAME", "PASSWORD"))
+}
+
+func TestTLSOptionsInvalid(t *testing.T) {
+	c := NewClient("https://example.jp")
+	assert.NotNil(t, c.SetTLSOptions(TLSOptions{CACertFile: filepath.Join(t.TempDir(), "notexist.pem")}))
+	assert.NotNil(t, c.SetTLSOptions(TLSOptions{CertFile: "client.pem"}))
+}
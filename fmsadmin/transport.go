@@ -0,0 +1,70 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fmsadmin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// transport returns the transport of the HTTP client, which is created from
+// http.DefaultTransport so that the connection settings can be changed.
+func (c *Client) transport() *http.Transport {
+	if t, ok := c.HTTPClient.Transport.(*http.Transport); ok {
+		return t
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	c.HTTPClient.Transport = t
+
+	return t
+}
+
+// SetProxy makes the HTTP client connect to the server through the proxy
+// (e.g. "http://proxy.example.jp:3128" for HTTP CONNECT or
+// "socks5://127.0.0.1:1080" for SOCKS5) instead of the proxy specified by
+// the environment variables.
+func (c *Client) SetProxy(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme: %s", proxyURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL: %s", proxyURL)
+	}
+
+	c.transport().Proxy = http.ProxyURL(u)
+
+	return nil
+}
+
+// SetDialContext makes the HTTP client open connections to the server with
+// dial (e.g. through an SSH tunnel). No proxy is used with dial.
+func (c *Client) SetDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) {
+	t := c.transport()
+	t.Proxy = nil
+	t.DialContext = dial
+}
@@ -0,0 +1,64 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fmsadmin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "fms.example.invalid", r.URL.Host)
+		assert.Equal(t, "/fmi/admin/api/v2/server/status", r.URL.Path)
+		fmt.Fprintln(w, `{"response": {"status": "RUNNING"}, "messages": [{"code": "0"}]}`)
+	}))
+	defer proxy.Close()
+
+	c := NewClient("http://fms.example.invalid")
+	assert.Nil(t, c.SetProxy(proxy.URL))
+	status, err := c.ServerStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, "RUNNING", status)
+
+	assert.NotNil(t, c.SetProxy("ftp://proxy.example.jp"))
+	assert.NotNil(t, c.SetProxy("socks5://"))
+}
+
+func TestSetDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": {"status": "RUNNING"}, "messages": [{"code": "0"}]}`)
+	}))
+	defer ts.Close()
+
+	addrs := []string{}
+	c := NewClient("http://fms.example.invalid:16001")
+	c.SetDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		addrs = append(addrs, addr)
+		return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+	})
+	status, err := c.ServerStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, "RUNNING", status)
+	assert.Equal(t, []string{"fms.example.invalid:16001"}, addrs)
+}
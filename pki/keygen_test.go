@@ -0,0 +1,63 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalPrivateKey(t *testing.T) {
+	key := readTestKey(t)
+
+	data, err := MarshalPrivateKey(key, nil)
+	assert.Nil(t, err)
+	assert.False(t, IsEncrypted(data))
+	parsed, keyType, err := ParsePrivateKey(data, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "PRIVATE KEY", keyType)
+	assert.True(t, key.Equal(parsed))
+
+	data, err = MarshalPrivateKey(key, []byte("passphrase"))
+	assert.Nil(t, err)
+	assert.True(t, IsEncrypted(data))
+	parsed, keyType, err = ParsePrivateKey(data, []byte("passphrase"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ENCRYPTED PRIVATE KEY", keyType)
+	assert.True(t, key.Equal(parsed))
+
+	_, _, err = ParsePrivateKey(data, []byte("incorrect"))
+	assert.True(t, errors.Is(err, ErrIncorrectPassphrase))
+}
+
+func TestMarshalPublicKey(t *testing.T) {
+	key := readTestKey(t)
+
+	data, err := MarshalPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	block, _ := pem.Decode(data)
+	assert.Equal(t, "PUBLIC KEY", block.Type)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	assert.Nil(t, err)
+	assert.True(t, key.PublicKey.Equal(pub.(*rsa.PublicKey)))
+}
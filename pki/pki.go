@@ -0,0 +1,192 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pki reads private keys for PKI authentication of FileMaker Admin
+// API and certificate import. It supports PKCS #1 and SEC 1 keys (optionally
+// encrypted with the traditional OpenSSL PEM encryption) and PKCS #8 keys
+// (optionally encrypted with PBES2), and generates key pairs for PKI
+// authentication. The signing input of a JSON Web Token can also be signed by
+// ssh-agent or an external command.
+package pki
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidKey is returned when the data is not a private key.
+	ErrInvalidKey = errors.New("pki: invalid private key")
+
+	// ErrPassphraseRequired is returned when the private key is encrypted
+	// and no passphrase is given.
+	ErrPassphraseRequired = errors.New("pki: passphrase required")
+
+	// ErrIncorrectPassphrase is returned when the private key cannot be
+	// decrypted with the passphrase.
+	ErrIncorrectPassphrase = errors.New("pki: incorrect passphrase")
+)
+
+// IsEncrypted reports whether the PEM-encoded private key is encrypted.
+func IsEncrypted(data []byte) bool {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return false
+	}
+
+	return block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+}
+
+// ParsePrivateKey parses the PEM-encoded private key, decrypting it with the
+// passphrase if encrypted. The key is returned as *rsa.PrivateKey,
+// *ecdsa.PrivateKey or ed25519.PrivateKey like x509.ParsePKCS8PrivateKey.
+// The PEM type of the key is also returned.
+func ParsePrivateKey(data []byte, passphrase []byte) (interface{}, string, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, "", ErrInvalidKey
+	}
+
+	der := block.Bytes
+	if IsEncrypted(data) && len(passphrase) == 0 {
+		return nil, block.Type, ErrPassphraseRequired
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY", "EC PRIVATE KEY":
+		encrypted := strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+		if encrypted {
+			der, err = decryptPEMBlock(block, passphrase)
+			if err != nil {
+				return nil, block.Type, err
+			}
+		}
+		if block.Type == "RSA PRIVATE KEY" {
+			key, err = x509.ParsePKCS1PrivateKey(der)
+		} else {
+			key, err = x509.ParseECPrivateKey(der)
+		}
+		if err != nil && encrypted {
+			// the padding happened to be valid with a wrong passphrase
+			return nil, block.Type, ErrIncorrectPassphrase
+		}
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		der, err = decryptPKCS8(der, passphrase)
+		if err != nil {
+			return nil, block.Type, err
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			// the padding happened to be valid with a wrong passphrase
+			return nil, block.Type, ErrIncorrectPassphrase
+		}
+	default:
+		return nil, block.Type, fmt.Errorf("%w: unsupported PEM type %q", ErrInvalidKey, block.Type)
+	}
+	if err != nil {
+		return nil, block.Type, fmt.Errorf("%w: %s", ErrInvalidKey, err.Error())
+	}
+
+	return key, block.Type, nil
+}
+
+// decryptPEMBlock decrypts the block encrypted with the traditional OpenSSL
+// PEM encryption ("Proc-Type: 4,ENCRYPTED"), whose key is derived from the
+// passphrase with EVP_BytesToKey (MD5, one iteration).
+func decryptPEMBlock(block *pem.Block, passphrase []byte) ([]byte, error) {
+	mode, ivHex, ok := strings.Cut(block.Headers["DEK-Info"], ",")
+	if !ok {
+		return nil, fmt.Errorf("%w: no DEK-Info header", ErrInvalidKey)
+	}
+	iv, err := hex.DecodeString(ivHex)
+	if err != nil || len(iv) < 8 {
+		return nil, fmt.Errorf("%w: invalid DEK-Info header", ErrInvalidKey)
+	}
+
+	keySize := 0
+	var newCipher func([]byte) (cipher.Block, error)
+	switch mode {
+	case "AES-128-CBC":
+		keySize, newCipher = 16, aes.NewCipher
+	case "AES-192-CBC":
+		keySize, newCipher = 24, aes.NewCipher
+	case "AES-256-CBC":
+		keySize, newCipher = 32, aes.NewCipher
+	case "DES-EDE3-CBC":
+		keySize, newCipher = 24, des.NewTripleDESCipher
+	case "DES-CBC":
+		keySize, newCipher = 8, des.NewCipher
+	default:
+		return nil, fmt.Errorf("%w: unsupported cipher %s", ErrInvalidKey, mode)
+	}
+
+	key := []byte{}
+	digest := []byte{}
+	for len(key) < keySize {
+		h := md5.New()
+		h.Write(digest)
+		h.Write(passphrase)
+		h.Write(iv[:8])
+		digest = h.Sum(nil)
+		key = append(key, digest...)
+	}
+
+	c, err := newCipher(key[:keySize])
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptCBC(c, iv, block.Bytes)
+}
+
+// decryptCBC decrypts the data and removes the PKCS #7 padding. A wrong
+// passphrase is detected by an invalid padding.
+func decryptCBC(c cipher.Block, iv []byte, data []byte) ([]byte, error) {
+	if len(iv) != c.BlockSize() {
+		return nil, fmt.Errorf("%w: invalid IV", ErrInvalidKey)
+	}
+	if len(data) == 0 || len(data)%c.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: invalid encrypted data", ErrInvalidKey)
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(out, data)
+
+	n := int(out[len(out)-1])
+	if n == 0 || n > c.BlockSize() {
+		return nil, ErrIncorrectPassphrase
+	}
+	for _, b := range out[len(out)-n:] {
+		if int(b) != n {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+
+	return out[:len(out)-n], nil
+}
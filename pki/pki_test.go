@@ -0,0 +1,77 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readTestData(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.Nil(t, err)
+	return data
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	expected, _, err := ParsePrivateKey(readTestData(t, "rsa_pkcs1.pem"), nil)
+	assert.Nil(t, err)
+	assert.IsType(t, &rsa.PrivateKey{}, expected)
+
+	for _, name := range []string{"rsa_pkcs8.pem", "rsa_pkcs1_aes256.pem", "rsa_pkcs8_aes256.pem", "rsa_pkcs8_des3.pem"} {
+		key, _, err := ParsePrivateKey(readTestData(t, name), []byte("passphrase"))
+		assert.Nil(t, err, name)
+		assert.True(t, expected.(*rsa.PrivateKey).Equal(key), name)
+	}
+
+	key, keyType, err := ParsePrivateKey(readTestData(t, "ec_pkcs8.pem"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "PRIVATE KEY", keyType)
+	assert.IsType(t, &ecdsa.PrivateKey{}, key)
+}
+
+func TestParseEncryptedPrivateKey(t *testing.T) {
+	for _, name := range []string{"rsa_pkcs1_aes256.pem", "rsa_pkcs8_aes256.pem", "rsa_pkcs8_des3.pem"} {
+		data := readTestData(t, name)
+		assert.True(t, IsEncrypted(data), name)
+
+		_, _, err := ParsePrivateKey(data, nil)
+		assert.True(t, errors.Is(err, ErrPassphraseRequired), name)
+
+		_, _, err = ParsePrivateKey(data, []byte("wrong"))
+		assert.True(t, errors.Is(err, ErrIncorrectPassphrase), name)
+	}
+	assert.False(t, IsEncrypted(readTestData(t, "rsa_pkcs8.pem")))
+
+	// PBES1 is not supported
+	_, _, err := ParsePrivateKey(readTestData(t, "rsa_pkcs8_pbes1.pem"), []byte("passphrase"))
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+}
+
+func TestParseInvalidPrivateKey(t *testing.T) {
+	_, _, err := ParsePrivateKey([]byte("not a key"), nil)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+
+	_, _, err = ParsePrivateKey([]byte("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"), nil)
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+}
@@ -0,0 +1,213 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AgentPrefix is the prefix of an identity that refers to a key in ssh-agent
+// (e.g. "agent:Admin_Key" or "agent:SHA256:...").
+const AgentPrefix = "agent:"
+
+// ErrAgentKeyNotFound is returned when ssh-agent has no RSA key matching the
+// selector.
+var ErrAgentKeyNotFound = errors.New("pki: RSA key not found in ssh-agent")
+
+// A Signer creates RS256 (RSASSA-PKCS1-v1_5 using SHA-256) signatures of the
+// signing input of a JSON Web Token.
+type Signer interface {
+	Sign(signingInput []byte) ([]byte, error)
+}
+
+// KeySigner signs with an RSA private key.
+type KeySigner struct {
+	Key *rsa.PrivateKey
+}
+
+// Sign returns the RS256 signature of signingInput.
+func (s *KeySigner) Sign(signingInput []byte) ([]byte, error) {
+	digest := sha256.Sum256(signingInput)
+
+	return rsa.SignPKCS1v15(rand.Reader, s.Key, crypto.SHA256, digest[:])
+}
+
+// AgentSigner signs with an RSA key held by ssh-agent.
+type AgentSigner struct {
+	// Socket is the path of the socket of ssh-agent (SSH_AUTH_SOCK).
+	Socket string
+	// Selector is the comment, the file name without the extension or the
+	// SHA256 fingerprint of the key. The only RSA key of ssh-agent is used
+	// when it is empty.
+	Selector string
+}
+
+// NewAgentSigner returns an AgentSigner for the identity "agent:SELECTOR"
+// using the ssh-agent of SSH_AUTH_SOCK.
+func NewAgentSigner(identity string) *AgentSigner {
+	return &AgentSigner{
+		Socket:   os.Getenv("SSH_AUTH_SOCK"),
+		Selector: strings.TrimPrefix(identity, AgentPrefix),
+	}
+}
+
+// Comment returns the comment of the selected key.
+func (s *AgentSigner) Comment() (string, error) {
+	var comment string
+	err := s.withKey(func(_ agent.ExtendedAgent, key *agent.Key) error {
+		comment = key.Comment
+		return nil
+	})
+
+	return comment, err
+}
+
+// Sign returns the RS256 signature of signingInput.
+func (s *AgentSigner) Sign(signingInput []byte) ([]byte, error) {
+	var signature []byte
+	err := s.withKey(func(client agent.ExtendedAgent, key *agent.Key) error {
+		sig, err := client.SignWithFlags(key, signingInput, agent.SignatureFlagRsaSha256)
+		if err != nil {
+			return fmt.Errorf("pki: ssh-agent: %w", err)
+		}
+		if sig.Format != ssh.KeyAlgoRSASHA256 {
+			return fmt.Errorf("pki: ssh-agent returned a %s signature", sig.Format)
+		}
+		signature = sig.Blob
+		return nil
+	})
+
+	return signature, err
+}
+
+func (s *AgentSigner) withKey(f func(agent.ExtendedAgent, *agent.Key) error) error {
+	if s.Socket == "" {
+		return errors.New("pki: SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", s.Socket)
+	if err != nil {
+		return fmt.Errorf("pki: ssh-agent: %w", err)
+	}
+	defer conn.Close()
+
+	client := agent.NewClient(conn)
+	keys, err := client.List()
+	if err != nil {
+		return fmt.Errorf("pki: ssh-agent: %w", err)
+	}
+
+	var found []*agent.Key
+	for _, key := range keys {
+		if key.Type() != ssh.KeyAlgoRSA {
+			continue
+		}
+		if s.Selector == "" || matchAgentKey(key, s.Selector) {
+			found = append(found, key)
+		}
+	}
+	switch {
+	case len(found) == 0:
+		return ErrAgentKeyNotFound
+	case len(found) > 1:
+		return fmt.Errorf("pki: ssh-agent has %d matching RSA keys", len(found))
+	}
+
+	return f(client, found[0])
+}
+
+func matchAgentKey(key *agent.Key, selector string) bool {
+	if ssh.FingerprintSHA256(key) == selector || key.Comment == selector {
+		return true
+	}
+
+	// keys added with ssh-add have the file path as the comment
+	name := filepath.Base(key.Comment)
+	return strings.TrimSuffix(name, filepath.Ext(name)) == selector
+}
+
+// CommandSigner signs with an external command such as a wrapper of a
+// PKCS #11 token or an HSM. The command receives the signing input on the
+// standard input and prints the signature in binary or in base64.
+type CommandSigner struct {
+	Command string
+}
+
+// NewCommandSigner returns a CommandSigner running command with the shell.
+func NewCommandSigner(command string) *CommandSigner {
+	return &CommandSigner{Command: command}
+}
+
+// Sign returns the RS256 signature of signingInput.
+func (s *CommandSigner) Sign(signingInput []byte) ([]byte, error) {
+	if strings.TrimSpace(s.Command) == "" {
+		return nil, errors.New("pki: no signer command")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", s.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", s.Command)
+	}
+	cmd.Stdin = bytes.NewReader(signingInput)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pki: %s: %w", s.Command, err)
+	}
+
+	signature := decodeSignature(out)
+	if len(signature) == 0 {
+		return nil, fmt.Errorf("pki: %s: empty signature", s.Command)
+	}
+
+	return signature, nil
+}
+
+// decodeSignature decodes the output of a signer command in base64 or
+// base64url, and returns any other output as is.
+func decodeSignature(out []byte) []byte {
+	text := strings.TrimSpace(string(out))
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding,
+		base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		if signature, err := encoding.DecodeString(text); err == nil && len(signature) > 0 {
+			return signature
+		}
+	}
+
+	return out
+}
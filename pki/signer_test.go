@@ -0,0 +1,130 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func readTestKey(t *testing.T) *rsa.PrivateKey {
+	key, _, err := ParsePrivateKey(readTestData(t, "rsa_pkcs1.pem"), nil)
+	assert.Nil(t, err)
+	return key.(*rsa.PrivateKey)
+}
+
+func verify(t *testing.T, key *rsa.PrivateKey, signingInput []byte, signature []byte) {
+	digest := sha256.Sum256(signingInput)
+	assert.Nil(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestKeySigner(t *testing.T) {
+	key := readTestKey(t)
+	signingInput := []byte("header.payload")
+
+	signature, err := (&KeySigner{Key: key}).Sign(signingInput)
+	assert.Nil(t, err)
+	verify(t, key, signingInput, signature)
+}
+
+func TestAgentSigner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ssh-agent is served on a Unix domain socket")
+	}
+
+	key := readTestKey(t)
+	keyring := agent.NewKeyring()
+	assert.Nil(t, keyring.Add(agent.AddedKey{PrivateKey: key, Comment: "/home/admin/keys/Admin_Key.pem"}))
+
+	dir, err := os.MkdirTemp("", "agent")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.Nil(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	signingInput := []byte("header.payload")
+	for _, selector := range []string{"", "Admin_Key", "/home/admin/keys/Admin_Key.pem"} {
+		signer := &AgentSigner{Socket: socket, Selector: selector}
+		comment, err := signer.Comment()
+		assert.Nil(t, err)
+		assert.Equal(t, "/home/admin/keys/Admin_Key.pem", comment)
+
+		signature, err := signer.Sign(signingInput)
+		assert.Nil(t, err)
+		verify(t, key, signingInput, signature)
+	}
+
+	_, err = (&AgentSigner{Socket: socket, Selector: "Other_Key"}).Sign(signingInput)
+	assert.True(t, errors.Is(err, ErrAgentKeyNotFound))
+
+	_, err = (&AgentSigner{Selector: "Admin_Key"}).Sign(signingInput)
+	assert.NotNil(t, err)
+}
+
+func TestNewAgentSigner(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/agent.sock")
+	signer := NewAgentSigner("agent:Admin_Key")
+	assert.Equal(t, "/tmp/agent.sock", signer.Socket)
+	assert.Equal(t, "Admin_Key", signer.Selector)
+}
+
+func TestCommandSigner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test signer is a shell command")
+	}
+
+	// base64 output
+	signature, err := NewCommandSigner(`test "$(cat)" = header.payload && echo c2lnbmF0dXJl`).Sign([]byte("header.payload"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("signature"), signature)
+
+	// binary output
+	signature, err = NewCommandSigner(`printf '\001\377'`).Sign([]byte("header.payload"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x01, 0xff}, signature)
+
+	_, err = NewCommandSigner("exit 1").Sign([]byte("header.payload"))
+	assert.NotNil(t, err)
+
+	_, err = NewCommandSigner("true").Sign([]byte("header.payload"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,126 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package profile loads named server profiles of fmcsadmin from the
+// configuration file.
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvName is the environment variable to select a profile.
+const EnvName = "FMCSADMIN_PROFILE"
+
+// ErrNotFound is returned when the profile is not defined.
+var ErrNotFound = errors.New("profile not found")
+
+// Config is the content of the configuration file.
+type Config struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is the settings to administer a server.
+type Profile struct {
+	FQDN             string `yaml:"fqdn"`
+	URL              string `yaml:"url"`
+	Username         string `yaml:"username"`
+	IdentityFile     string `yaml:"identity_file"`
+	KeyName          string `yaml:"key_name"`
+	TokenLifetime    string `yaml:"token_lifetime"`
+	SignerCommand    string `yaml:"signer_command"`
+	PasswordCommand  string `yaml:"password_command"`
+	CredentialHelper string `yaml:"credential_helper"`
+	Output           string `yaml:"output"`
+	Proxy            string `yaml:"proxy"`
+	SSHJump          string `yaml:"ssh_jump"`
+	TLS              TLS    `yaml:"tls"`
+}
+
+// TLS is the TLS settings to connect to the server.
+type TLS struct {
+	CACert    string `yaml:"cacert"`
+	Cert      string `yaml:"cert"`
+	Key       string `yaml:"key"`
+	PinSHA256 string `yaml:"pin_sha256"`
+	Insecure  bool   `yaml:"insecure"`
+}
+
+// DefaultPath returns the path of the configuration file
+// ("~/.config/fmcsadmin/config.yaml").
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "fmcsadmin", "config.yaml"), nil
+}
+
+// Load reads the configuration file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &config, nil
+}
+
+// Get returns the profile. The file paths in the profile are expanded when
+// they start with "~/".
+func (c *Config) Get(name string) (*Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	p.IdentityFile = expandHome(p.IdentityFile)
+	p.TLS.CACert = expandHome(p.TLS.CACert)
+	p.TLS.Cert = expandHome(p.TLS.Cert)
+	p.TLS.Key = expandHome(p.TLS.Key)
+
+	return &p, nil
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,87 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `profiles:
+  prod-tokyo:
+    fqdn: fms.example.jp
+    url: https://proxy.example.jp:8443/fms-admin
+    username: admin
+    identity_file: ~/keys/Admin_Key.pem
+    key_name: Admin
+    token_lifetime: 5m
+    signer_command: hsm-sign
+    password_command: pass show fms/prod
+    credential_helper: /usr/local/bin/fms-credential
+    output: json
+    ssh_jump: admin@bastion.example.jp
+    tls:
+      cacert: /etc/ssl/ca.pem
+      insecure: true
+`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+
+	config, err := Load(path)
+	assert.Nil(t, err)
+
+	p, err := config.Get("prod-tokyo")
+	assert.Nil(t, err)
+	assert.Equal(t, "fms.example.jp", p.FQDN)
+	assert.Equal(t, "https://proxy.example.jp:8443/fms-admin", p.URL)
+	assert.Equal(t, "admin", p.Username)
+	assert.Equal(t, "Admin", p.KeyName)
+	assert.Equal(t, "5m", p.TokenLifetime)
+	assert.Equal(t, "hsm-sign", p.SignerCommand)
+	assert.Equal(t, "pass show fms/prod", p.PasswordCommand)
+	assert.Equal(t, "/usr/local/bin/fms-credential", p.CredentialHelper)
+	assert.Equal(t, "json", p.Output)
+	assert.Equal(t, "admin@bastion.example.jp", p.SSHJump)
+	assert.Equal(t, "/etc/ssl/ca.pem", p.TLS.CACert)
+	assert.True(t, p.TLS.Insecure)
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, filepath.Join(home, "keys", "Admin_Key.pem"), p.IdentityFile)
+
+	_, err = config.Get("notexist")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("profiles: ["), 0600))
+
+	_, err := Load(path)
+	assert.NotNil(t, err)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	path, err := DefaultPath()
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join("/tmp/config", "fmcsadmin", "config.yaml"), path)
+}
@@ -0,0 +1,180 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package session caches the access tokens of FileMaker Admin API so that
+// a session created by "fmcsadmin login" is shared by subsequent commands.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// IdleTimeout is the period after which the server invalidates an access
+// token that is not used.
+const IdleTimeout = 15 * time.Minute
+
+type entry struct {
+	Token    string    `json:"token"`
+	Identity string    `json:"identity,omitempty"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// Cache is a file that stores the access token of each server.
+type Cache struct {
+	Path string
+}
+
+// DefaultPath returns the path of the cache file in the user cache directory
+// (e.g. "~/.cache/fmcsadmin/sessions.json").
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "fmcsadmin", "sessions.json"), nil
+}
+
+// NewCache returns the cache stored in the file at path.
+func NewCache(path string) *Cache {
+	return &Cache{Path: path}
+}
+
+// Get returns the cached access token of the server and the identity (e.g.
+// the username) which the token was issued to, or empty strings when no
+// token is cached or the token has expired.
+func (c *Cache) Get(server string) (string, string, error) {
+	entries, err := c.load()
+	if err != nil {
+		return "", "", err
+	}
+
+	e, ok := entries[server]
+	if !ok || time.Since(e.LastUsed) >= IdleTimeout {
+		return "", "", nil
+	}
+
+	return e.Token, e.Identity, nil
+}
+
+// Put stores the access token of the server and the identity which the token
+// was issued to.
+func (c *Cache) Put(server string, token string, identity string) error {
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	entries[server] = entry{Token: token, Identity: identity, LastUsed: time.Now()}
+
+	return c.save(entries)
+}
+
+// Touch records that the cached access token of the server has just been
+// used.
+func (c *Cache) Touch(server string) error {
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	e, ok := entries[server]
+	if !ok {
+		return nil
+	}
+	e.LastUsed = time.Now()
+	entries[server] = e
+
+	return c.save(entries)
+}
+
+// Delete removes the access token of the server from the cache.
+func (c *Cache) Delete(server string) error {
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := entries[server]; !ok {
+		return nil
+	}
+	delete(entries, server)
+
+	return c.save(entries)
+}
+
+func (c *Cache) load() (map[string]entry, error) {
+	entries := map[string]entry{}
+
+	data, err := os.ReadFile(c.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		// start over with an empty cache when the file is broken
+		return map[string]entry{}, nil
+	}
+
+	return entries, nil
+}
+
+func (c *Cache) save(entries map[string]entry) error {
+	if len(entries) == 0 {
+		err := os.Remove(c.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
+		return err
+	}
+
+	// write the tokens to a private file and replace the cache with it
+	f, err := os.CreateTemp(filepath.Dir(c.Path), ".sessions-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), c.Path)
+}
@@ -0,0 +1,72 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fmcsadmin", "sessions.json")
+	c := NewCache(path)
+
+	token, identity, err := c.Get("https://fms.example.jp")
+	assert.Nil(t, err)
+	assert.Equal(t, "", token)
+	assert.Equal(t, "", identity)
+
+	assert.Nil(t, c.Put("https://fms.example.jp", "TOKEN1", "user:admin"))
+	assert.Nil(t, c.Put("https://fms2.example.jp", "TOKEN2", ""))
+	assert.Nil(t, c.Touch("https://fms.example.jp"))
+	token, identity, err = c.Get("https://fms.example.jp")
+	assert.Nil(t, err)
+	assert.Equal(t, "TOKEN1", token)
+	assert.Equal(t, "user:admin", identity)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	assert.Nil(t, c.Delete("https://fms.example.jp"))
+	token, _, _ = c.Get("https://fms.example.jp")
+	assert.Equal(t, "", token)
+	token, _, _ = c.Get("https://fms2.example.jp")
+	assert.Equal(t, "TOKEN2", token)
+
+	// the cache file is removed with the last token
+	assert.Nil(t, c.Delete("https://fms2.example.jp"))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCacheExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	data := `{"https://fms.example.jp": {"token": "TOKEN", "lastUsed": "` + time.Now().Add(-IdleTimeout).Format(time.RFC3339) + `"}}`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+
+	token, _, err := NewCache(path).Get("https://fms.example.jp")
+	assert.Nil(t, err)
+	assert.Equal(t, "", token)
+}
@@ -0,0 +1,171 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tunnel opens connections to the server through an SSH connection
+// to a bastion host, like "ssh -J user@bastion".
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Jump opens connections through the bastion host. It connects to the
+// bastion host on the first use.
+type Jump struct {
+	Target string
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// NewJump returns a Jump through the bastion host specified as
+// "[user@]host[:port]".
+func NewJump(target string) *Jump {
+	return &Jump{Target: target}
+}
+
+// DialContext opens a connection to addr from the bastion host.
+func (j *Jump) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	j.mu.Lock()
+	if j.client == nil {
+		client, err := Dial(j.Target)
+		if err != nil {
+			j.mu.Unlock()
+			return nil, fmt.Errorf("ssh %s: %w", j.Target, err)
+		}
+		j.client = client
+	}
+	client := j.client
+	j.mu.Unlock()
+
+	return client.DialContext(ctx, network, addr)
+}
+
+// Close closes the connection to the bastion host.
+func (j *Jump) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.client == nil {
+		return nil
+	}
+	err := j.client.Close()
+	j.client = nil
+
+	return err
+}
+
+// identityFiles are the private keys tried in the ~/.ssh directory.
+var identityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// ParseTarget returns the user name and the address of the bastion host
+// specified as "[user@]host[:port]".
+func ParseTarget(target string) (string, string, error) {
+	username := ""
+	host := strings.TrimSpace(target)
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		username, host = host[:i], host[i+1:]
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid SSH host: %s", target)
+	}
+
+	if username == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", "", err
+		}
+		username = u.Username
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+
+	return username, host, nil
+}
+
+// Dial connects to the bastion host. The user is authenticated with the
+// keys of the running ssh-agent (SSH_AUTH_SOCK) and the unencrypted private
+// keys in ~/.ssh, and the host key is verified with ~/.ssh/known_hosts.
+func Dial(target string) (*ssh.Client, error) {
+	username, addr, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	sshDir := filepath.Join(home, ".ssh")
+
+	hostKeyCallback, err := knownhosts.New(filepath.Join(sshDir, "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify the host key of %s: %w", addr, err)
+	}
+
+	auth := []ssh.AuthMethod{}
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			defer conn.Close()
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	signers := []ssh.Signer{}
+	for _, name := range identityFiles {
+		data, err := os.ReadFile(filepath.Join(sshDir, name))
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			// skip encrypted keys, which should be added to ssh-agent
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("no SSH key is available (start ssh-agent or create a key in ~/.ssh)")
+	}
+
+	config := &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	}
+
+	return ssh.Dial("tcp", addr, config)
+}
@@ -0,0 +1,171 @@
+/*
+fmcsadmin
+Copyright 2017-2026 Emic Corporation, https://www.emic.co.jp/
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestParseTarget(t *testing.T) {
+	username, addr, err := ParseTarget("admin@bastion.example.jp")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", username)
+	assert.Equal(t, "bastion.example.jp:22", addr)
+
+	username, addr, err = ParseTarget("admin@bastion.example.jp:2222")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", username)
+	assert.Equal(t, "bastion.example.jp:2222", addr)
+
+	_, addr, err = ParseTarget("admin@[2001:db8::1]")
+	assert.Nil(t, err)
+	assert.Equal(t, "[2001:db8::1]:22", addr)
+
+	_, _, err = ParseTarget("admin@")
+	assert.NotNil(t, err)
+}
+
+// startSSHServer starts an SSH server that accepts the public key and
+// forwards "direct-tcpip" channels.
+func startSSHServer(t *testing.T, hostKey ssh.Signer, userKey ssh.PublicKey) net.Listener {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "admin" && string(key.Marshal()) == string(userKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				for newChannel := range channels {
+					payload := struct {
+						Host     string
+						Port     uint32
+						OrigHost string
+						OrigPort uint32
+					}{}
+					if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &payload) != nil {
+						_ = newChannel.Reject(ssh.UnknownChannelType, "not supported")
+						continue
+					}
+					dest, err := net.Dial("tcp", net.JoinHostPort(payload.Host, fmt.Sprint(payload.Port)))
+					if err != nil {
+						_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, channelRequests, _ := newChannel.Accept()
+					go ssh.DiscardRequests(channelRequests)
+					go func() {
+						_, _ = io.Copy(channel, dest)
+						channel.Close()
+					}()
+					go func() {
+						_, _ = io.Copy(dest, channel)
+						dest.Close()
+					}()
+				}
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestDial(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	}))
+	defer ts.Close()
+
+	_, hostPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(hostPrivateKey)
+	assert.Nil(t, err)
+	_, userPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+	userKey, err := ssh.NewSignerFromKey(userPrivateKey)
+	assert.Nil(t, err)
+
+	l := startSSHServer(t, hostKey, userKey.PublicKey())
+	defer l.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	sshDir := filepath.Join(home, ".ssh")
+	assert.Nil(t, os.MkdirAll(sshDir, 0700))
+
+	// the host key is not known
+	_, err = Dial("admin@" + l.Addr().String())
+	assert.NotNil(t, err)
+
+	block, err := ssh.MarshalPrivateKey(userPrivateKey, "")
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(filepath.Join(sshDir, "id_ed25519"), pem.EncodeToMemory(block), 0600))
+	line := knownhosts.Line([]string{knownhosts.Normalize(l.Addr().String())}, hostKey.PublicKey())
+	assert.Nil(t, os.WriteFile(filepath.Join(sshDir, "known_hosts"), []byte(line+"\n"), 0600))
+
+	client, err := Dial("admin@" + l.Addr().String())
+	assert.Nil(t, err)
+	defer client.Close()
+
+	httpClient := &http.Client{Transport: &http.Transport{DialContext: client.DialContext}}
+	res, err := httpClient.Get(ts.URL)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "OK", string(body))
+
+	jump := NewJump("admin@" + l.Addr().String())
+	httpClient = &http.Client{Transport: &http.Transport{DialContext: jump.DialContext}}
+	res, err = httpClient.Get(ts.URL)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Nil(t, jump.Close())
+
+	// the user is not allowed
+	_, err = Dial("guest@" + l.Addr().String())
+	assert.NotNil(t, err)
+}